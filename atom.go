@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// AtomFeed represents a parsed Atom 1.0 feed
+type AtomFeed struct {
+	Base     string      `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Title    AtomText    `xml:"title"`
+	Subtitle AtomText    `xml:"subtitle"`
+	Links    []AtomLink  `xml:"link"`
+	Entries  []AtomEntry `xml:"entry"`
+}
+
+// AtomEntry represents a single entry in an Atom feed
+type AtomEntry struct {
+	Base       string         `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	ID         string         `xml:"id"`
+	Title      AtomText       `xml:"title"`
+	Links      []AtomLink     `xml:"link"`
+	Summary    AtomText       `xml:"summary"`
+	Content    AtomText       `xml:"content"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Authors    []AtomPerson   `xml:"author"`
+	Categories []AtomCategory `xml:"category"`
+}
+
+// AtomText represents an Atom text construct such as a title, summary or content
+// Text and html constructs hold their text as character data, while xhtml constructs
+// hold markup wrapped in a <div>, which has to be read as raw XML
+type AtomText struct {
+	Type     string `xml:"type,attr"`
+	Text     string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+// xhtmlWrapper matches the <div> an xhtml text construct is wrapped in
+var xhtmlWrapper = regexp.MustCompile(`(?s)^\s*<(?:[\w-]+:)?div\b[^>]*>(.*)</(?:[\w-]+:)?div>\s*$`)
+
+// String returns the content of the construct, as HTML markup for xhtml constructs
+func (t AtomText) String() string {
+	if t.Type != "xhtml" {
+		return t.Text
+	}
+	if match := xhtmlWrapper.FindStringSubmatch(t.InnerXML); match != nil {
+		return match[1]
+	}
+	return t.InnerXML
+}
+
+// PlainText returns the content of the construct without markup, for titles
+func (t AtomText) PlainText() string {
+	if t.Type != "xhtml" {
+		return t.Text
+	}
+	return strings.Join(strings.Fields(htmlTag.ReplaceAllString(t.String(), " ")), " ")
+}
+
+// AtomPerson represents an Atom author or contributor
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+// AtomCategory represents an Atom category, whose name is in the term attribute
+type AtomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+// AtomLink represents an Atom link element, which carries its target in attributes
+type AtomLink struct {
+	Base   string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// alternateLink returns the href of the rel="alternate" link
+// A link without a rel attribute is treated as alternate, as the Atom spec requires
+func alternateLink(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+// resolveURL resolves a possibly relative reference against a base URL
+// The reference is returned unchanged when either of them can't be parsed
+func resolveURL(base, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if base == "" {
+		return ref
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// resolveLinks returns the links with their hrefs resolved against base and their own xml:base
+func resolveLinks(links []AtomLink, base string) []AtomLink {
+	resolved := make([]AtomLink, 0, len(links))
+	for _, link := range links {
+		if strings.TrimSpace(link.Href) != "" {
+			link.Href = resolveURL(resolveURL(base, link.Base), link.Href)
+		}
+		resolved = append(resolved, link)
+	}
+	return resolved
+}
+
+// parseAtomFeed parses an Atom document and maps it onto the RSSFeed model
+// so that the rest of the aggregator can treat every feed format the same way
+// Relative links are resolved against xml:base attributes, starting from the feed's URL
+func parseAtomFeed(body []byte, feedURL string) (*RSSFeed, error) {
+	var atom AtomFeed
+	if err := unmarshalXML(body, &atom); err != nil {
+		return nil, fmt.Errorf("error parsing Atom feed: %w", err)
+	}
+	feedBase := resolveURL(feedURL, atom.Base)
+
+	var feed RSSFeed
+	feed.Channel.Title = atom.Title.PlainText()
+	feed.Channel.Link = alternateLink(resolveLinks(atom.Links, feedBase))
+	feed.Channel.Description = atom.Subtitle.String()
+
+	for _, entry := range atom.Entries {
+		links := resolveLinks(entry.Links, resolveURL(feedBase, entry.Base))
+
+		// Prefer the short summary, falling back to the full content
+		description := entry.Summary.String()
+		if strings.TrimSpace(description) == "" {
+			description = entry.Content.String()
+		}
+
+		// Prefer the original publication date, falling back to the last update
+		pubDate := entry.Published
+		if pubDate == "" {
+			pubDate = entry.Updated
+		}
+
+		item := RSSItem{
+			Title:       entry.Title.PlainText(),
+			Link:        alternateLink(links),
+			Description: description,
+			PubDate:     strings.TrimSpace(pubDate),
+			GUID:        strings.TrimSpace(entry.ID),
+			Content:     entry.Content.String(),
+		}
+
+		var authors []string
+		for _, author := range entry.Authors {
+			if name := strings.TrimSpace(author.Name); name != "" {
+				authors = append(authors, name)
+			}
+		}
+		item.Author = strings.Join(authors, ", ")
+
+		for _, category := range entry.Categories {
+			item.Categories = append(item.Categories, category.Term)
+		}
+
+		// Atom attaches media files as rel="enclosure" links
+		for _, link := range links {
+			if link.Rel == "enclosure" {
+				item.Enclosures = append(item.Enclosures, RSSEnclosure{
+					URL:    strings.TrimSpace(link.Href),
+					Type:   link.Type,
+					Length: link.Length,
+				})
+			}
+		}
+
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	return &feed, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import "testing"
+
+func TestAtomTextString(t *testing.T) {
+	tests := []struct {
+		name string
+		text AtomText
+		want string
+	}{
+		{"text", AtomText{Type: "text", Text: "Hello & goodbye"}, "Hello & goodbye"},
+		{"html", AtomText{Type: "html", Text: "<p>Hello</p>"}, "<p>Hello</p>"},
+		{"untyped", AtomText{Text: "Hello"}, "Hello"},
+		{
+			"xhtml",
+			AtomText{Type: "xhtml", InnerXML: `<div xmlns="http://www.w3.org/1999/xhtml"><p>Hello <b>world</b></p></div>`},
+			"<p>Hello <b>world</b></p>",
+		},
+		{
+			"xhtml with whitespace around the div",
+			AtomText{Type: "xhtml", InnerXML: "\n  <div xmlns=\"http://www.w3.org/1999/xhtml\">\n<p>Hello</p>\n</div>\n"},
+			"\n<p>Hello</p>\n",
+		},
+		{
+			"prefixed xhtml div",
+			AtomText{Type: "xhtml", InnerXML: `<xhtml:div><xhtml:p>Hello</xhtml:p></xhtml:div>`},
+			"<xhtml:p>Hello</xhtml:p>",
+		},
+		{"xhtml without a div", AtomText{Type: "xhtml", InnerXML: "<p>Hello</p>"}, "<p>Hello</p>"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.text.String(); got != tt.want {
+			t.Errorf("%s: String() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAtomTextPlainText(t *testing.T) {
+	tests := []struct {
+		name string
+		text AtomText
+		want string
+	}{
+		{"text", AtomText{Type: "text", Text: "A <b> tag"}, "A <b> tag"},
+		{
+			"xhtml",
+			AtomText{Type: "xhtml", InnerXML: `<div xmlns="http://www.w3.org/1999/xhtml">My <b>Blog</b></div>`},
+			"My Blog",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.text.PlainText(); got != tt.want {
+			t.Errorf("%s: PlainText() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		base string
+		ref  string
+		want string
+	}{
+		{"https://example.com/feeds/atom.xml", "post.html", "https://example.com/feeds/post.html"},
+		{"https://example.com/feeds/atom.xml", "/post.html", "https://example.com/post.html"},
+		{"https://example.com/feeds/atom.xml", "../post.html", "https://example.com/post.html"},
+		{"https://example.com/feeds/atom.xml", "https://other.example/post", "https://other.example/post"},
+		{"https://example.com/feeds/atom.xml", "//cdn.example/a.mp3", "https://cdn.example/a.mp3"},
+		{"https://example.com/blog/", "", "https://example.com/blog/"},
+		{"", "post.html", "post.html"},
+		{"https://example.com/", " post.html ", "https://example.com/post.html"},
+	}
+
+	for _, tt := range tests {
+		if got := resolveURL(tt.base, tt.ref); got != tt.want {
+			t.Errorf("resolveURL(%q, %q) = %q, want %q", tt.base, tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestParseAtomFeedLinks(t *testing.T) {
+	body := []byte(`<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:base="/blog/">
+  <title>Blog</title>
+  <link href="./"/>
+  <entry>
+    <id>urn:1</id>
+    <title>Relative to the feed's xml:base</title>
+    <link href="first.html"/>
+  </entry>
+  <entry xml:base="2024/">
+    <id>urn:2</id>
+    <title>Relative to the entry's xml:base</title>
+    <link href="second.html"/>
+    <link rel="enclosure" href="/media/second.mp3" type="audio/mpeg"/>
+  </entry>
+  <entry>
+    <id>urn:3</id>
+    <title>Relative to the link's xml:base</title>
+    <link xml:base="https://mirror.example/" href="third.html"/>
+  </entry>
+  <entry>
+    <id>urn:4</id>
+    <title>Absolute</title>
+    <link href="https://other.example/fourth"/>
+  </entry>
+</feed>`)
+
+	feed, err := parseAtomFeed(body, "https://example.com/feeds/atom.xml")
+	if err != nil {
+		t.Fatalf("parseAtomFeed: %v", err)
+	}
+	if want := "https://example.com/blog/"; feed.Channel.Link != want {
+		t.Errorf("channel link = %q, want %q", feed.Channel.Link, want)
+	}
+
+	want := []string{
+		"https://example.com/blog/first.html",
+		"https://example.com/blog/2024/second.html",
+		"https://mirror.example/third.html",
+		"https://other.example/fourth",
+	}
+	if len(feed.Channel.Items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(feed.Channel.Items), len(want))
+	}
+	for i, item := range feed.Channel.Items {
+		if item.Link != want[i] {
+			t.Errorf("%s: link = %q, want %q", item.Title, item.Link, want[i])
+		}
+	}
+
+	enclosures := feed.Channel.Items[1].Enclosures
+	if len(enclosures) != 1 || enclosures[0].URL != "https://example.com/media/second.mp3" {
+		t.Errorf("enclosures = %+v, want https://example.com/media/second.mp3", enclosures)
+	}
+}
+
+func TestParseAtomFeedXHTML(t *testing.T) {
+	body := []byte(`<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml">My <b>Blog</b></div></title>
+  <entry>
+    <id>urn:1</id>
+    <title type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml">An <em>xhtml</em> title</div></title>
+    <summary type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Short</p></div></summary>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Full &amp; long</p></div></content>
+  </entry>
+</feed>`)
+
+	feed, err := parseAtomFeed(body, "https://example.com/atom.xml")
+	if err != nil {
+		t.Fatalf("parseAtomFeed: %v", err)
+	}
+	if want := "My Blog"; feed.Channel.Title != want {
+		t.Errorf("channel title = %q, want %q", feed.Channel.Title, want)
+	}
+	if len(feed.Channel.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(feed.Channel.Items))
+	}
+
+	item := feed.Channel.Items[0]
+	tests := []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"title", item.Title, "An xhtml title"},
+		{"description", item.Description, "<p>Short</p>"},
+		{"content", item.Content, "<p>Full &amp; long</p>"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// charmap maps the upper half (0x80-0xFF) of a single-byte character set to Unicode
+// The lower half is always ASCII
+type charmap [128]rune
+
+// windows1252 is used for ISO-8859-1 as well, as browsers do, since it only
+// replaces rarely used control characters with printable ones
+var windows1252 = func() charmap {
+	var table charmap
+	for i := range table {
+		table[i] = rune(0x80 + i)
+	}
+	copy(table[:0x20], []rune{
+		'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡',
+		'ˆ', '‰', 'Š', '‹', 'Œ', '\u008D', 'Ž', '\u008F',
+		'\u0090', '‘', '’', '“', '”', '•', '–', '—',
+		'˜', '™', 'š', '›', 'œ', '\u009D', 'ž', 'Ÿ',
+	})
+	return table
+}()
+
+// iso885915 is Latin-1 with the euro sign and a few French and Finnish letters
+var iso885915 = func() charmap {
+	var table charmap
+	for i := range table {
+		table[i] = rune(0x80 + i)
+	}
+	replacements := map[byte]rune{
+		0xA4: '€', 0xA6: 'Š', 0xA8: 'š', 0xB4: 'Ž',
+		0xB8: 'ž', 0xBC: 'Œ', 0xBD: 'œ', 0xBE: 'Ÿ',
+	}
+	for b, r := range replacements {
+		table[b-0x80] = r
+	}
+	return table
+}()
+
+// windows1251 is the Windows Cyrillic code page
+var windows1251 = func() charmap {
+	var table charmap
+	copy(table[:0x40], []rune{
+		'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡',
+		'€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+		'ђ', '‘', '’', '“', '”', '•', '–', '—',
+		'\u0098', '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+		'\u00A0', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§',
+		'Ё', '©', 'Є', '«', '¬', '\u00AD', '®', 'Ї',
+		'°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·',
+		'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+	})
+	// А through я are contiguous
+	for i := 0x40; i < 0x80; i++ {
+		table[i] = rune(0x0410 + i - 0x40)
+	}
+	return table
+}()
+
+// koi8r is the KOI8-R Cyrillic encoding
+var koi8r = func() charmap {
+	var table charmap
+	copy(table[:0x40], []rune{
+		'─', '│', '┌', '┐', '└', '┘', '├', '┤',
+		'┬', '┴', '┼', '▀', '▄', '█', '▌', '▐',
+		'░', '▒', '▓', '⌠', '■', '∙', '√', '≈',
+		'≤', '≥', '\u00A0', '⌡', '°', '²', '·', '÷',
+		'═', '║', '╒', 'ё', '╓', '╔', '╕', '╖',
+		'╗', '╘', '╙', '╚', '╛', '╜', '╝', '╞',
+		'╟', '╠', '╡', 'Ё', '╢', '╣', '╤', '╥',
+		'╦', '╧', '╨', '╩', '╪', '╫', '╬', '©',
+	})
+	// Lowercase letters follow the order of the Latin alphabet, uppercase ones mirror them
+	lower := []rune("юабцдефгхийклмнопярстужвьызшэщчъ")
+	for i, r := range lower {
+		table[0x40+i] = r
+		table[0x60+i] = r - 0x20
+	}
+	return table
+}()
+
+// charmaps lists the supported single-byte character sets by their lowercase labels
+var charmaps = map[string]*charmap{
+	"iso-8859-1":   &windows1252,
+	"iso8859-1":    &windows1252,
+	"latin1":       &windows1252,
+	"us-ascii":     &windows1252,
+	"windows-1252": &windows1252,
+	"cp1252":       &windows1252,
+	"iso-8859-15":  &iso885915,
+	"iso8859-15":   &iso885915,
+	"latin-9":      &iso885915,
+	"windows-1251": &windows1251,
+	"cp1251":       &windows1251,
+	"koi8-r":       &koi8r,
+}
+
+// isUTF8Label reports whether a charset label names UTF-8
+func isUTF8Label(label string) bool {
+	label = strings.ToLower(strings.TrimSpace(label))
+	return label == "" || label == "utf-8" || label == "utf8"
+}
+
+// decode converts text in the character set to UTF-8
+func (table *charmap) decode(data []byte) []byte {
+	decoded := make([]byte, 0, len(data))
+	for _, b := range data {
+		if b < 0x80 {
+			decoded = append(decoded, b)
+			continue
+		}
+		decoded = utf8.AppendRune(decoded, table[b-0x80])
+	}
+	return decoded
+}
+
+// charsetReader converts input in the named character set to UTF-8
+// It is used as the xml.Decoder CharsetReader for feeds that declare a non-UTF-8 encoding
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	if isUTF8Label(label) {
+		return input, nil
+	}
+	table, ok := charmaps[strings.ToLower(strings.TrimSpace(label))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported character set: %s", label)
+	}
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(table.decode(data)), nil
+}
+
+// xmlEncodingDeclaration matches the encoding attribute of an XML declaration
+var xmlEncodingDeclaration = regexp.MustCompile(`^(\s*<\?xml[^>]*?encoding\s*=\s*)["'][^"']*["']`)
+
+// transcodeToUTF8 converts a response body to UTF-8 when its Content-Type names another charset
+// The charset from the HTTP header takes precedence over the document's own XML declaration,
+// which is rewritten so that the XML decoder doesn't convert the body a second time
+func transcodeToUTF8(body []byte, contentType string) ([]byte, error) {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil || isUTF8Label(params["charset"]) {
+		return body, nil
+	}
+	table, ok := charmaps[strings.ToLower(strings.TrimSpace(params["charset"]))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported character set: %s", params["charset"])
+	}
+	decoded := table.decode(body)
+	return xmlEncodingDeclaration.ReplaceAll(decoded, []byte(`${1}"UTF-8"`)), nil
+}
+
+// unmarshalXML is xml.Unmarshal with support for the character sets in charmaps
+func unmarshalXML(data []byte, v any) error {
+	return newXMLDecoder(data).Decode(v)
+}
+
+// newXMLDecoder creates an XML decoder that understands the character sets in charmaps
+func newXMLDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charsetReader
+	return decoder
+}
@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestCharmapDecode(t *testing.T) {
+	tests := []struct {
+		label string
+		input byte
+		want  string
+	}{
+		{"windows-1252", 0x41, "A"},
+		{"windows-1252", 0x80, "€"},
+		{"windows-1252", 0x9F, "Ÿ"},
+		{"windows-1252", 0xA9, "©"},
+		{"windows-1252", 0xC9, "É"},
+		{"windows-1252", 0xE9, "é"},
+
+		{"iso-8859-15", 0x41, "A"},
+		{"iso-8859-15", 0x80, "\u0080"},
+		{"iso-8859-15", 0xA4, "€"},
+		{"iso-8859-15", 0xBD, "œ"},
+		{"iso-8859-15", 0xC0, "À"},
+		{"iso-8859-15", 0xFF, "ÿ"},
+
+		{"windows-1251", 0x41, "A"},
+		{"windows-1251", 0x80, "Ђ"},
+		{"windows-1251", 0xA8, "Ё"},
+		{"windows-1251", 0xB9, "№"},
+		{"windows-1251", 0xC0, "А"},
+		{"windows-1251", 0xFF, "я"},
+
+		{"koi8-r", 0x41, "A"},
+		{"koi8-r", 0x80, "─"},
+		{"koi8-r", 0xA3, "ё"},
+		{"koi8-r", 0xB3, "Ё"},
+		{"koi8-r", 0xC1, "а"},
+		{"koi8-r", 0xE1, "А"},
+		{"koi8-r", 0xFF, "Ъ"},
+	}
+
+	for _, tt := range tests {
+		table, ok := charmaps[tt.label]
+		if !ok {
+			t.Fatalf("charset %s is not supported", tt.label)
+		}
+		got := string(table.decode([]byte{tt.input}))
+		if got != tt.want {
+			t.Errorf("%s: decode(0x%02X) = %q, want %q", tt.label, tt.input, got, tt.want)
+		}
+	}
+}
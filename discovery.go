@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// htmlPageError is returned by fetchFeed when the URL serves a web page instead of a feed
+// It keeps the page so that the feeds it links to can be discovered
+type htmlPageError struct {
+	URL  string
+	Body []byte
+}
+
+func (e *htmlPageError) Error() string {
+	return fmt.Sprintf("%s is an HTML page, not a feed", e.URL)
+}
+
+// isHTMLPage reports whether a response is a web page
+// Servers often label feeds text/html and XHTML pages may start with an XML declaration,
+// so JSON bodies are feeds and XML documents are told apart by their root element.
+// Only bodies that are neither are judged by an HTML Content-Type or a sniffed <html> start.
+func isHTMLPage(body []byte, contentType string) bool {
+	start := bytes.ToLower(bytes.TrimSpace(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))))
+	if bytes.HasPrefix(start, []byte("{")) {
+		return false
+	}
+	if root, err := rootElement(body); err == nil {
+		switch root.Local {
+		case "rss", "feed", "RDF":
+			return false
+		case "html":
+			return true
+		}
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml") {
+		return true
+	}
+	return bytes.HasPrefix(start, []byte("<!doctype html")) || bytes.HasPrefix(start, []byte("<html"))
+}
+
+// feedLinkTypes are the link types that announce a feed
+var feedLinkTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/rdf+xml":   true,
+	"application/feed+json": true,
+}
+
+var (
+	linkTagPattern   = regexp.MustCompile(`(?is)<link\b[^>]*>`)
+	attributePattern = regexp.MustCompile(`(?s)([a-zA-Z-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// feedCandidate is a feed announced by a web page
+type feedCandidate struct {
+	URL   string
+	Title string
+	Type  string
+}
+
+// discoverFeedLinks finds the <link rel="alternate"> feeds of an HTML page
+// Relative URLs are resolved against the page URL and duplicates are dropped
+func discoverFeedLinks(page []byte, pageURL string) []feedCandidate {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []feedCandidate
+	seen := map[string]bool{}
+	for _, tag := range linkTagPattern.FindAll(page, -1) {
+		attributes := map[string]string{}
+		for _, match := range attributePattern.FindAllSubmatch(tag, -1) {
+			value := string(match[2]) + string(match[3]) + string(match[4])
+			attributes[strings.ToLower(string(match[1]))] = html.UnescapeString(value)
+		}
+
+		// rel is a space-separated list, as in rel="alternate feed"
+		if !containsToken(attributes["rel"], "alternate") {
+			continue
+		}
+		linkType := strings.ToLower(strings.TrimSpace(attributes["type"]))
+		if !feedLinkTypes[linkType] {
+			continue
+		}
+		href, err := url.Parse(strings.TrimSpace(attributes["href"]))
+		if err != nil || attributes["href"] == "" {
+			continue
+		}
+
+		feedURL := base.ResolveReference(href).String()
+		if seen[feedURL] {
+			continue
+		}
+		seen[feedURL] = true
+		candidates = append(candidates, feedCandidate{
+			URL:   feedURL,
+			Title: strings.TrimSpace(attributes["title"]),
+			Type:  linkType,
+		})
+	}
+	return candidates
+}
+
+// containsToken reports whether a space-separated attribute value contains token
+func containsToken(value, token string) bool {
+	for _, field := range strings.Fields(strings.ToLower(value)) {
+		if field == token {
+			return true
+		}
+	}
+	return false
+}
+
+// chooseFeedCandidate picks the feed to add among those a page announces
+// With several candidates the user is asked to choose; an empty answer picks the first
+func chooseFeedCandidate(candidates []feedCandidate, in io.Reader, out io.Writer) (feedCandidate, error) {
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	fmt.Fprintln(out, "The page links to several feeds:")
+	for i, candidate := range candidates {
+		title := candidate.Title
+		if title == "" {
+			title = candidate.URL
+		}
+		fmt.Fprintf(out, "  %d. %s (%s)\n     %s\n", i+1, title, candidate.Type, candidate.URL)
+	}
+	fmt.Fprintf(out, "Choose a feed [1-%d, default 1]: ", len(candidates))
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return feedCandidate{}, fmt.Errorf("couldn't read choice: %w", err)
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return candidates[0], nil
+	}
+	choice, err := strconv.Atoi(answer)
+	if err != nil || choice < 1 || choice > len(candidates) {
+		return feedCandidate{}, fmt.Errorf("invalid choice: %s", answer)
+	}
+	return candidates[choice-1], nil
+}
+
+// resolveFeed fetches the URL given to addfeed and makes sure it is a feed
+// When it is a web page instead, the feeds the page links to are offered and the chosen
+// one is fetched in turn. It returns the URL to store along with the parsed feed.
+func resolveFeed(ctx context.Context, s *state, rawURL string, in io.Reader, out io.Writer) (string, *fetchResult, error) {
+	feedURL := rawURL
+	result, err := s.fetcher.fetchFeed(ctx, feedURL, "", "")
+
+	var pageErr *htmlPageError
+	if errors.As(err, &pageErr) {
+		candidates := discoverFeedLinks(pageErr.Body, pageErr.URL)
+		if len(candidates) == 0 {
+			return "", nil, fmt.Errorf("%s is a web page that doesn't link to any feed", rawURL)
+		}
+		candidate, err := chooseFeedCandidate(candidates, in, out)
+		if err != nil {
+			return "", nil, err
+		}
+		fmt.Fprintf(out, "Found feed %s\n", candidate.URL)
+
+		feedURL = candidate.URL
+		result, err = s.fetcher.fetchFeed(ctx, feedURL, "", "")
+		if err != nil {
+			return "", nil, fmt.Errorf("couldn't fetch discovered feed %s: %w", feedURL, err)
+		}
+	} else if err != nil {
+		return "", nil, fmt.Errorf("couldn't fetch feed %s: %w", rawURL, err)
+	}
+
+	// Any XML document decodes into an RSSFeed, so require a title or at least one item
+	if result.Feed.Channel.Title == "" && len(result.Feed.Channel.Items) == 0 {
+		return "", nil, fmt.Errorf("%s doesn't look like an RSS, Atom or JSON feed", feedURL)
+	}
+
+	// Store where the feed lives now rather than an address that permanently redirects
+	if result.MovedTo != "" {
+		feedURL = result.MovedTo
+	}
+	return feedURL, result, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+const (
+	// defaultFetchTimeout bounds a whole feed request, including reading the body
+	defaultFetchTimeout = 30 * time.Second
+	// defaultMaxFeedBytes is the largest feed body accepted after decompression
+	defaultMaxFeedBytes = 10 << 20
+)
+
+// feedFetcher holds the HTTP client shared by every feed request, so that
+// connections to the same host are reused between fetches
+type feedFetcher struct {
+	client       *http.Client
+	maxFeedBytes int64
+}
+
+// newFeedFetcher creates a fetcher with the given request timeout and body size limit
+// A timeout of "" or a size of 0 or less falls back to the defaults
+func newFeedFetcher(timeout string, maxFeedBytes int64) (*feedFetcher, error) {
+	requestTimeout := defaultFetchTimeout
+	if timeout != "" {
+		parsed, err := time.ParseDuration(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fetch timeout: %w", err)
+		}
+		requestTimeout = parsed
+	}
+	if maxFeedBytes <= 0 {
+		maxFeedBytes = defaultMaxFeedBytes
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 4
+	transport.IdleConnTimeout = 90 * time.Second
+	transport.ResponseHeaderTimeout = requestTimeout
+	// Content encodings are negotiated and decoded by fetchFeed itself
+	transport.DisableCompression = true
+
+	return &feedFetcher{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   requestTimeout,
+		},
+		maxFeedBytes: maxFeedBytes,
+	}, nil
+}
+
+// readBody decodes the response body according to its Content-Encoding and reads it,
+// failing once more than maxFeedBytes of decoded content have been read
+func (f *feedFetcher) readBody(response *http.Response) ([]byte, error) {
+	body, err := decodeBody(response.Body, response.Header.Get("Content-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(body, f.maxFeedBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	if int64(len(data)) > f.maxFeedBytes {
+		return nil, fmt.Errorf("feed is larger than the %d byte limit", f.maxFeedBytes)
+	}
+	return data, nil
+}
+
+// decodeBody wraps a response body in a decompressor for its Content-Encoding
+func decodeBody(body io.Reader, contentEncoding string) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
+		return io.NopCloser(body), nil
+	case "gzip", "x-gzip":
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding gzip body: %w", err)
+		}
+		return reader, nil
+	case "deflate":
+		// HTTP deflate is meant to be zlib-wrapped, but some servers send raw deflate data
+		buffered := bufio.NewReader(body)
+		header, err := buffered.Peek(2)
+		if err == nil && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 && header[0]&0x0f == 8 {
+			reader, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding deflate body: %w", err)
+			}
+			return reader, nil
+		}
+		return flate.NewReader(buffered), nil
+	case "br":
+		return io.NopCloser(brotli.NewReader(body)), nil
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", contentEncoding)
+	}
+}
@@ -3,6 +3,7 @@ module github.com/phihdn/gator
 go 1.24.3
 
 require (
+	github.com/andybalholm/brotli v1.2.6
 	github.com/google/uuid v1.6.0
 	github.com/lib/pq v1.10.9
 )
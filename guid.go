@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query parameters that only identify where a visitor came from
+// Parameters starting with utm_ are dropped as well
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"igshid":  true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+}
+
+// postGUID returns the identity used to deduplicate an item within its feed
+// The item's own GUID is preferred, then its normalized link, then a hash of its content
+func postGUID(item RSSItem) string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+	if link := normalizeURL(item.Link); link != "" {
+		return link
+	}
+	sum := sha256.Sum256([]byte(item.Title + "\n" + item.Description))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// normalizeURL canonicalizes a link so that the same article shared with different
+// tracking parameters or fragments maps to the same value
+func normalizeURL(link string) string {
+	link = strings.TrimSpace(link)
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host == "" {
+		return link
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	if port := parsed.Port(); (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		parsed.Host = parsed.Hostname()
+	}
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+
+	query := parsed.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") || trackingParams[strings.ToLower(key)] {
+			query.Del(key)
+		}
+	}
+	// Encode sorts the parameters by key
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String()
+}
@@ -3,105 +3,464 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/phihdn/gator/internal/database"
 )
 
+// aggOptions holds the settings of a running agg process
+// minInterval and maxInterval bound how often a single feed is polled
+type aggOptions struct {
+	concurrency   int
+	leaseOwner    string
+	leaseDuration time.Duration
+	minInterval   time.Duration
+	maxInterval   time.Duration
+}
+
 // handlerAgg processes the agg command
-// Usage: gator agg <time_between_reqs>
+// It runs until interrupted, or scrapes every due feed a single time with --once
+// Usage: gator agg [--concurrency N] [--lease duration] [--min-interval duration] [--max-interval duration] [--once] <time_between_reqs>
 func handlerAgg(s *state, cmd command) error {
-	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
-		return fmt.Errorf("usage: %v <time_between_reqs>", cmd.Name)
+	usage := fmt.Errorf("usage: %v [--concurrency N] [--lease duration] [--min-interval duration] [--max-interval duration] [--once] <time_between_reqs>", cmd.Name)
+
+	// Parse the optional flags before the positional interval argument
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	concurrency := flags.Int("concurrency", 1, "number of feeds to fetch in parallel per tick")
+	leaseDuration := flags.Duration("lease", 5*time.Minute, "how long a claimed feed is reserved for this process")
+	minInterval := flags.Duration("min-interval", 10*time.Minute, "shortest time between two fetches of the same feed")
+	maxInterval := flags.Duration("max-interval", 24*time.Hour, "longest time between two fetches of the same feed")
+	once := flags.Bool("once", false, "scrape every due feed a single time and exit")
+	if err := flags.Parse(cmd.Args); err != nil {
+		return usage
+	}
+	// The interval is only meaningful, and therefore only required, when running continuously
+	if (*once && flags.NArg() > 1) || (!*once && flags.NArg() != 1) {
+		return usage
+	}
+	if *concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1, got %d", *concurrency)
+	}
+	if *leaseDuration < time.Second {
+		return fmt.Errorf("lease must be at least 1s, got %s", *leaseDuration)
+	}
+	if *minInterval <= 0 || *maxInterval < *minInterval {
+		return fmt.Errorf("intervals must satisfy 0 < min-interval <= max-interval, got %s and %s", *minInterval, *maxInterval)
 	}
 
-	timeBetweenRequests, err := time.ParseDuration(cmd.Args[0])
+	opts := aggOptions{
+		concurrency:   *concurrency,
+		leaseOwner:    newLeaseOwner(),
+		leaseDuration: *leaseDuration,
+		minInterval:   *minInterval,
+		maxInterval:   *maxInterval,
+	}
+
+	// The first signal stops claiming new feeds and lets in-flight scrapes finish,
+	// a second one cancels the context they run with
+	stopping, stop := context.WithCancel(context.Background())
+	defer stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		log.Println("Shutting down, waiting for in-flight scrapes to finish (interrupt again to abort)...")
+		stop()
+		<-signals
+		log.Println("Aborting in-flight scrapes...")
+		cancel()
+	}()
+
+	if *once {
+		return aggregateOnce(ctx, stopping, s, opts)
+	}
+
+	timeBetweenRequests, err := time.ParseDuration(flags.Arg(0))
 	if err != nil {
 		return fmt.Errorf("invalid duration: %w", err)
 	}
 
-	log.Printf("Collecting feeds every %s...", timeBetweenRequests)
+	log.Printf("Collecting up to %d feeds every %s as %s...", opts.concurrency, timeBetweenRequests, opts.leaseOwner)
 
 	ticker := time.NewTicker(timeBetweenRequests)
+	defer ticker.Stop()
 
-	for ; ; <-ticker.C {
-		scrapeFeeds(s)
+	for {
+		if _, err := scrapeFeeds(ctx, s, opts, time.Now()); err != nil {
+			log.Println(err)
+		}
+
+		select {
+		case <-stopping.Done():
+			log.Println("Aggregator stopped")
+			return nil
+		case <-ticker.C:
+		}
 	}
+}
+
+// aggregateOnce scrapes every feed that is due a single time, batch by batch, and returns
+// an error if any of them failed or were deferred by their host, the due feeds couldn't be
+// claimed or a signal stopped it early, so that a cron job exits with a non-zero code
+func aggregateOnce(ctx, stopping context.Context, s *state, opts aggOptions) error {
+	// Only feeds not fetched since the run started are claimed, so each is scraped once
+	startedAt := time.Now()
 
-	// This line will never be reached due to the infinite loop above
+	log.Printf("Collecting every due feed once, %d at a time, as %s...", opts.concurrency, opts.leaseOwner)
+
+	var total scrapeCounts
+	finished := false
+	for stopping.Err() == nil {
+		batch, err := scrapeFeeds(ctx, s, opts, startedAt)
+		if err != nil {
+			return err
+		}
+		if batch.claimed == 0 {
+			finished = true
+			break
+		}
+		total.claimed += batch.claimed
+		total.failed += batch.failed
+		total.deferred += batch.deferred
+	}
+
+	log.Printf("Scraped %d feeds, %d failed, %d deferred", total.claimed, total.failed, total.deferred)
+	if !finished {
+		return fmt.Errorf("stopped after %d feeds, before every due feed was scraped", total.claimed)
+	}
+	if total.failed > 0 {
+		return fmt.Errorf("%d of %d feeds failed to scrape", total.failed, total.claimed)
+	}
+	if total.deferred > 0 {
+		return fmt.Errorf("%d of %d feeds were deferred by their host and not scraped", total.deferred, total.claimed)
+	}
 	return nil
 }
 
-// scrapeFeeds fetches the next feed to process and processes it
-func scrapeFeeds(s *state) {
-	feed, err := s.db.GetNextFeedToFetch(context.Background())
+// newLeaseOwner builds an identifier for this agg process that is unique across hosts
+func newLeaseOwner() string {
+	hostname, err := os.Hostname()
 	if err != nil {
-		log.Println("Couldn't get next feeds to fetch", err)
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()[:8])
+}
+
+// scrapeFeeds claims the next batch of feeds to process and scrapes them in parallel
+// Claiming leases the feeds to this process in the same statement, so neither concurrent workers
+// nor other agg processes get the same feed. Leases of crashed processes expire on their own.
+// Only feeds last fetched before fetchedBefore are claimed.
+// It returns how many feeds were claimed, failed and were deferred by their host, or an error
+// when no feeds could be claimed.
+func scrapeFeeds(ctx context.Context, s *state, opts aggOptions, fetchedBefore time.Time) (scrapeCounts, error) {
+	feeds, err := s.db.GetNextFeedsToFetch(ctx, database.GetNextFeedsToFetchParams{
+		LeaseOwner:    opts.leaseOwner,
+		LeaseSeconds:  int32(opts.leaseDuration / time.Second),
+		FetchedBefore: fetchedBefore,
+		BatchSize:     int32(opts.concurrency),
+	})
+	if err != nil {
+		return scrapeCounts{}, fmt.Errorf("couldn't get next feeds to fetch: %w", err)
+	}
+	log.Printf("Found %d feeds to fetch!", len(feeds))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	counts := scrapeCounts{claimed: len(feeds)}
+	for _, feed := range feeds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := processFeed(ctx, s, opts, feed)
+			var deferredErr *hostDeferredError
+			if errors.As(err, &deferredErr) {
+				mu.Lock()
+				counts.deferred++
+				mu.Unlock()
+			} else if err != nil {
+				log.Printf("Couldn't collect feed %s: %v", feed.Name, err)
+				mu.Lock()
+				counts.failed++
+				mu.Unlock()
+			}
+
+			// Hand the feed back so its lease doesn't block it until expiry,
+			// even when the scrape itself was aborted
+			err = s.db.ReleaseFeedLease(context.WithoutCancel(ctx), database.ReleaseFeedLeaseParams{
+				ID:         feed.ID,
+				LeaseOwner: opts.leaseOwner,
+			})
+			if err != nil {
+				log.Printf("Couldn't release lease on feed %s: %v", feed.Name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return counts, nil
+}
+
+// scrapeCounts tallies how the feeds of a batch turned out
+type scrapeCounts struct {
+	claimed  int
+	failed   int
+	deferred int
+}
+
+// fetchLogRetention is the number of fetch attempts kept per feed in the fetch log
+const fetchLogRetention = 100
+
+// processFeed scrapes a single feed and records the outcome, both in the feed's
+// error state and as a row in the fetch log, then schedules its next fetch
+// Feeds that moved permanently get their URL updated, feeds that are gone are marked dead
+// A feed whose host asked to back off is only rescheduled, and its *hostDeferredError returned
+func processFeed(ctx context.Context, s *state, opts aggOptions, feed database.Feed) error {
+	result, err := scrapeFeed(ctx, s, feed)
+
+	// The feed's host asked us to back off, so the feed wasn't fetched at all
+	var deferredErr *hostDeferredError
+	if errors.As(err, &deferredErr) {
+		log.Printf("Deferring feed %s: %v", feed.Name, deferredErr)
+		scheduleFeedFetch(ctx, s.db, feed, time.Until(deferredErr.until))
+		return err
+	}
+
+	recordFetchAttempt(ctx, s.db, feed, result, err)
+	if err != nil {
+		recordFetchFailure(ctx, s.db, feed, err)
+		// Retry when the server said it would be ready, or back off exponentially
+		retryDelay := failureBackoff(feed.ConsecutiveFailures+1, opts.maxInterval)
+		if result.retryAfter > 0 {
+			retryDelay = result.retryAfter
+		}
+		scheduleFeedFetch(ctx, s.db, feed, retryDelay)
+		if result.statusCode == http.StatusGone {
+			markFeedDead(ctx, s.db, feed)
+		}
+		return err
+	}
+	recordFetchSuccess(ctx, s.db, feed)
+	scheduleNextFetch(ctx, s.db, opts, feed, result.schedule)
+
+	if result.movedTo != "" {
+		if err := moveFeed(ctx, s, feed, result.movedTo); err != nil {
+			log.Printf("Couldn't move feed %s to %s: %v", feed.Name, result.movedTo, err)
+		}
+	}
+	return nil
+}
+
+// scheduleNextFetch works out when a successfully fetched feed is due again from
+// how often it posts and the polling hints of the feed and its server
+func scheduleNextFetch(ctx context.Context, db *database.Queries, opts aggOptions, feed database.Feed, schedule feedSchedule) {
+	averageSeconds, err := db.GetFeedPostingInterval(ctx, feed.ID)
+	if err != nil {
+		log.Printf("Couldn't get posting interval for feed %s: %v", feed.Name, err)
+	}
+	postingInterval := time.Duration(averageSeconds) * time.Second
+
+	delay := nextFetchDelay(time.Now(), postingInterval, schedule, opts.minInterval, opts.maxInterval)
+	scheduleFeedFetch(ctx, db, feed, delay)
+}
+
+// scheduleFeedFetch makes a feed due again after delay
+func scheduleFeedFetch(ctx context.Context, db *database.Queries, feed database.Feed, delay time.Duration) {
+	if ctx.Err() != nil {
 		return
 	}
-	log.Println("Found a feed to fetch!")
-	scrapeFeed(s.db, feed)
+	err := db.ScheduleFeedFetch(ctx, database.ScheduleFeedFetchParams{
+		DelaySeconds: int32(delay / time.Second),
+		ID:           feed.ID,
+	})
+	if err != nil {
+		log.Printf("Couldn't schedule next fetch of feed %s: %v", feed.Name, err)
+	}
 }
 
-// scrapeFeed processes a single feed
-func scrapeFeed(db *database.Queries, feed database.Feed) {
-	// Mark the feed as fetched with the current time
-	_, err := db.MarkFeedFetched(context.Background(), feed.ID)
+// markFeedDead stops agg from fetching a feed that the server reports as permanently gone
+func markFeedDead(ctx context.Context, db *database.Queries, feed database.Feed) {
+	if err := db.MarkFeedDead(ctx, feed.ID); err != nil {
+		log.Printf("Couldn't mark feed %s dead: %v", feed.Name, err)
+		return
+	}
+	log.Printf("Feed %s is gone, it won't be fetched again", feed.Name)
+}
+
+// recordFetchAttempt adds a row to the fetch log and prunes the feed's oldest attempts
+func recordFetchAttempt(ctx context.Context, db *database.Queries, feed database.Feed, result scrapeResult, fetchErr error) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	errorMessage := ""
+	if fetchErr != nil {
+		errorMessage = fetchErr.Error()
+	}
+
+	_, err := db.CreateFetchAttempt(ctx, database.CreateFetchAttemptParams{
+		ID:         uuid.New(),
+		FeedID:     feed.ID,
+		StartedAt:  result.fetchedAt,
+		DurationMs: int32(result.fetchDuration.Milliseconds()),
+		StatusCode: sql.NullInt32{
+			Int32: int32(result.statusCode),
+			Valid: result.statusCode != 0,
+		},
+		PostsFound: int32(result.postsFound),
+		PostsSaved: int32(result.postsSaved),
+		Error: sql.NullString{
+			String: errorMessage,
+			Valid:  errorMessage != "",
+		},
+	})
 	if err != nil {
-		log.Printf("Couldn't mark feed %s fetched: %v", feed.Name, err)
+		log.Printf("Couldn't record fetch attempt for feed %s: %v", feed.Name, err)
 		return
 	}
 
-	// Fetch the feed data
-	feedData, err := fetchFeed(context.Background(), feed.Url)
+	_, err = db.PruneFetchAttempts(ctx, database.PruneFetchAttemptsParams{
+		FeedID: feed.ID,
+		Keep:   fetchLogRetention,
+	})
 	if err != nil {
-		log.Printf("Couldn't collect feed %s: %v", feed.Name, err)
+		log.Printf("Couldn't prune fetch log for feed %s: %v", feed.Name, err)
+	}
+}
+
+// recordFetchFailure bumps the feed's consecutive failure count and stores the error,
+// which makes processFeed back off exponentially before retrying it
+// Aborted scrapes are not the feed's fault and aren't recorded
+func recordFetchFailure(ctx context.Context, db *database.Queries, feed database.Feed, fetchErr error) {
+	if ctx.Err() != nil {
 		return
 	}
+	err := db.MarkFeedFetchFailed(ctx, database.MarkFeedFetchFailedParams{
+		LastError: fetchErr.Error(),
+		ID:        feed.ID,
+	})
+	if err != nil {
+		log.Printf("Couldn't record failure for feed %s: %v", feed.Name, err)
+	}
+}
 
-	// Process and save feed items
-	savedCount := 0
-	for _, item := range feedData.Channel.Items {
-		// Parse the publication date
-		publishedAt, err := parsePubDate(item.PubDate)
-		if err != nil {
-			log.Printf("Warning: could not parse pubDate for post '%s': %v", item.Title, err)
-		}
+// recordFetchSuccess clears the feed's error state and records the time of the successful fetch
+func recordFetchSuccess(ctx context.Context, db *database.Queries, feed database.Feed) {
+	if err := db.MarkFeedFetchSucceeded(ctx, feed.ID); err != nil {
+		log.Printf("Couldn't record success for feed %s: %v", feed.Name, err)
+	}
+}
 
-		// Create a post in the database
-		_, err = db.CreatePost(context.Background(), database.CreatePostParams{
-			ID:        uuid.New(),
-			CreatedAt: time.Now().UTC(),
-			UpdatedAt: time.Now().UTC(),
-			Title:     item.Title,
-			Url:       item.Link,
-			Description: sql.NullString{
-				String: item.Description,
-				Valid:  item.Description != "",
-			},
-			PublishedAt: publishedAt,
-			FeedID:      feed.ID,
-		})
+// scrapeResult summarises a single scrape for the fetch log
+// fetchedAt and fetchDuration cover the request alone, not the wait for the host or saving posts
+type scrapeResult struct {
+	fetchedAt     time.Time
+	fetchDuration time.Duration
+	statusCode    int
+	postsFound    int
+	postsSaved    int
+	movedTo       string
+	schedule      feedSchedule
+	retryAfter    time.Duration
+}
 
-		if err != nil {
-			// Check if it's a duplicate post error (based on URL)
-			if err.Error() == "pq: duplicate key value violates unique constraint \"posts_url_key\"" {
-				// Silently ignore duplicates
-				continue
-			}
+// scrapeFeed processes a single feed
+// It returns an error if the feed couldn't be fetched, individual posts that fail to save are only logged
+func scrapeFeed(ctx context.Context, s *state, feed database.Feed) (scrapeResult, error) {
+	var scraped scrapeResult
+	db := s.db
 
-			// Log other errors
-			log.Printf("Error saving post '%s': %v", item.Title, err)
-			continue
+	// Wait for the feed's host to allow another request
+	host := hostOf(feed.Url)
+	release, err := s.limiter.acquire(ctx, host)
+	if err != nil {
+		return scraped, err
+	}
+
+	// Fetch the feed data, sending the cache validators from the previous fetch
+	scraped.fetchedAt = time.Now().UTC()
+	result, err := s.fetcher.fetchFeed(ctx, feed.Url, feed.Etag.String, feed.LastModified.String)
+	scraped.fetchDuration = time.Since(scraped.fetchedAt)
+	release()
+	if err != nil {
+		var statusErr *statusError
+		if errors.As(err, &statusErr) {
+			scraped.statusCode = statusErr.StatusCode
+			scraped.retryAfter = statusErr.RetryAfter
+			// Hold off every feed on the host, not just this one
+			if statusErr.RetryAfter > 0 {
+				s.limiter.deferHost(host, time.Now().Add(statusErr.RetryAfter))
+			}
 		}
+		return scraped, err
+	}
+	scraped.statusCode = result.StatusCode
+	scraped.movedTo = result.MovedTo
 
-		savedCount++
-		fmt.Printf("Saved post: %s\n", item.Title)
+	// Nothing changed since the last fetch, which was recorded when the feed was claimed,
+	// so the channel's polling hints are the ones stored when it was last downloaded
+	if result.NotModified {
+		scraped.schedule = newFeedSchedule(storedPollingHints(feed), result.CacheLifetime)
+		log.Printf("Feed %s not modified since last fetch", feed.Name)
+		return scraped, nil
+	}
+
+	feedData := result.Feed
+	scraped.postsFound = len(feedData.Channel.Items)
+
+	// Keep the channel's polling hints for the fetches answered with 304 Not Modified
+	hints := channelPollingHints(feedData)
+	scraped.schedule = newFeedSchedule(hints, result.CacheLifetime)
+	if err := storePollingHints(ctx, db, feed.ID, hints); err != nil {
+		log.Printf("Couldn't update polling hints for feed %s: %v", feed.Name, err)
+	}
+
+	// Process and save feed items
+	scraped.postsSaved, err = saveFeedItems(ctx, s, feed.ID, feedData.Channel.Items)
+	if err != nil {
+		return scraped, err
+	}
+
+	// Remember the validators for the next conditional request now that the items are stored
+	err = db.UpdateFeedCacheHeaders(ctx, database.UpdateFeedCacheHeadersParams{
+		ID: feed.ID,
+		Etag: sql.NullString{
+			String: result.ETag,
+			Valid:  result.ETag != "",
+		},
+		LastModified: sql.NullString{
+			String: result.LastModified,
+			Valid:  result.LastModified != "",
+		},
+	})
+	if err != nil {
+		log.Printf("Couldn't update cache headers for feed %s: %v", feed.Name, err)
+	}
+
+	// Keep the site link and description in step with the channel
+	err = db.UpdateFeedMetadata(ctx, database.UpdateFeedMetadataParams{
+		ID:          feed.ID,
+		SiteUrl:     channelSiteURL(feedData),
+		Description: channelDescription(feedData),
+	})
+	if err != nil {
+		log.Printf("Couldn't update metadata for feed %s: %v", feed.Name, err)
 	}
 
 	log.Printf("Feed %s collected, %v posts found, %v posts saved",
-		feed.Name, len(feedData.Channel.Items), savedCount)
+		feed.Name, scraped.postsFound, scraped.postsSaved)
+	return scraped, nil
 }
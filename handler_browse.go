@@ -2,21 +2,39 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"flag"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/phihdn/gator/internal/database"
 )
 
 // handlerBrowse processes the browse command
-// Usage: gator browse [limit]
+// Usage: gator browse [--folder name] [--category name] [--author name] [--unread] [limit]
 func handlerBrowse(s *state, cmd command, user database.User) error {
+	usage := fmt.Errorf("usage: %v [--folder name] [--category name] [--author name] [--unread] [limit]", cmd.Name)
+
+	// Parse the optional filters before the positional limit argument
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	folder := flags.String("folder", "", "only show posts from feeds in this folder or its subfolders")
+	category := flags.String("category", "", "only show posts in this category")
+	author := flags.String("author", "", "only show posts whose author contains this text")
+	unread := flags.Bool("unread", false, "only show posts that haven't been marked as read")
+	if err := flags.Parse(cmd.Args); err != nil {
+		return usage
+	}
+	if flags.NArg() > 1 {
+		return usage
+	}
+
 	// Default limit to 2 if not provided
 	limit := int32(2)
 
 	// Parse limit from args if provided
-	if len(cmd.Args) >= 1 {
-		parsedLimit, err := strconv.Atoi(cmd.Args[0])
+	if flags.NArg() == 1 {
+		parsedLimit, err := strconv.Atoi(flags.Arg(0))
 		if err != nil {
 			return fmt.Errorf("invalid limit: %w", err)
 		}
@@ -26,7 +44,20 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 	// Get posts for the user
 	posts, err := s.db.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
 		UserID: user.ID,
-		Limit:  limit,
+		Folder: sql.NullString{
+			String: cleanFolder(*folder),
+			Valid:  cleanFolder(*folder) != "",
+		},
+		Category: sql.NullString{
+			String: *category,
+			Valid:  *category != "",
+		},
+		Author: sql.NullString{
+			String: *author,
+			Valid:  *author != "",
+		},
+		UnreadOnly: *unread,
+		MaxPosts:   limit,
 	})
 	if err != nil {
 		return fmt.Errorf("error getting posts: %w", err)
@@ -42,15 +73,39 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 			publishedAt = "unknown date"
 		}
 
+		fmt.Printf("ID: %s\n", post.ID)
 		fmt.Printf("Feed: %s\n", post.FeedName)
 		fmt.Printf("Title: %s\n", post.Title)
+		if post.Author.Valid {
+			fmt.Printf("Author: %s\n", post.Author.String)
+		}
 		fmt.Printf("Published: %s\n", publishedAt)
+		// Flag posts the publisher edited after we first stored them
+		if post.Revision > 1 {
+			fmt.Printf("Updated: %s (revision %d)\n", post.UpdatedAt.Format("Jan 02, 2006"), post.Revision)
+		}
 		fmt.Printf("URL: %s\n", post.Url)
 
 		if post.Description.Valid {
 			fmt.Printf("Description: %s\n", post.Description.String)
 		}
 
+		categories, err := s.db.GetPostCategories(context.Background(), post.ID)
+		if err != nil {
+			return fmt.Errorf("error getting categories: %w", err)
+		}
+		if len(categories) > 0 {
+			fmt.Printf("Categories: %s\n", strings.Join(categories, ", "))
+		}
+
+		enclosures, err := s.db.GetPostEnclosures(context.Background(), post.ID)
+		if err != nil {
+			return fmt.Errorf("error getting enclosures: %w", err)
+		}
+		for _, enclosure := range enclosures {
+			printEnclosure(enclosure)
+		}
+
 		fmt.Println("--------------------")
 	}
 
@@ -60,3 +115,19 @@ func handlerBrowse(s *state, cmd command, user database.User) error {
 
 	return nil
 }
+
+// printEnclosure prints an attached media file with its type and size when known
+func printEnclosure(enclosure database.PostEnclosure) {
+	var details []string
+	if enclosure.Type.Valid {
+		details = append(details, enclosure.Type.String)
+	}
+	if enclosure.Length.Valid {
+		details = append(details, fmt.Sprintf("%d bytes", enclosure.Length.Int64))
+	}
+	if len(details) == 0 {
+		fmt.Printf("Enclosure: %s\n", enclosure.Url)
+		return
+	}
+	fmt.Printf("Enclosure: %s (%s)\n", enclosure.Url, strings.Join(details, ", "))
+}
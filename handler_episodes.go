@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/phihdn/gator/internal/database"
+)
+
+// handlerEpisodes processes the episodes command
+// It lists the latest podcast episodes from the feeds the user follows
+// Usage: gator episodes [limit]
+func handlerEpisodes(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) > 1 {
+		return fmt.Errorf("usage: %v [limit]", cmd.Name)
+	}
+
+	// Default limit to 10 if not provided
+	limit := int32(10)
+	if len(cmd.Args) == 1 {
+		parsedLimit, err := strconv.Atoi(cmd.Args[0])
+		if err != nil {
+			return fmt.Errorf("invalid limit: %w", err)
+		}
+		limit = int32(parsedLimit)
+	}
+
+	episodes, err := s.db.GetEpisodesForUser(context.Background(), database.GetEpisodesForUserParams{
+		UserID: user.ID,
+		Limit:  limit,
+	})
+	if err != nil {
+		return fmt.Errorf("error getting episodes: %w", err)
+	}
+
+	if len(episodes) == 0 {
+		fmt.Println("No episodes found. Try following some podcast feeds first!")
+		return nil
+	}
+
+	fmt.Printf("Found %d episodes for %s:\n\n", len(episodes), user.Name)
+	for _, episode := range episodes {
+		fmt.Printf("Podcast: %s\n", episode.FeedName)
+		if episode.Episode.Valid {
+			fmt.Printf("Episode: %d - %s\n", episode.Episode.Int32, episode.Title)
+		} else {
+			fmt.Printf("Episode: %s\n", episode.Title)
+		}
+		if episode.PublishedAt.Valid {
+			fmt.Printf("Published: %s\n", episode.PublishedAt.Time.Format("Jan 02, 2006"))
+		}
+		if episode.DurationSeconds.Valid {
+			fmt.Printf("Duration: %s\n", time.Duration(episode.DurationSeconds.Int32)*time.Second)
+		}
+		if episode.MediaType.Valid {
+			fmt.Printf("Media: %s (%s)\n", episode.MediaUrl, episode.MediaType.String)
+		} else {
+			fmt.Printf("Media: %s\n", episode.MediaUrl)
+		}
+		if episode.ImageUrl.Valid {
+			fmt.Printf("Image: %s\n", episode.ImageUrl.String)
+		}
+		if episode.TranscriptUrl.Valid {
+			fmt.Printf("Transcript: %s\n", episode.TranscriptUrl.String)
+		}
+		fmt.Println("--------------------")
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -12,27 +13,51 @@ import (
 	"github.com/phihdn/gator/internal/database"
 )
 
+// feedName returns the name to give a feed added without one: its channel title,
+// or the host of its URL when the channel has no title
+func feedName(feed *RSSFeed, feedURL string) string {
+	if title := strings.TrimSpace(feed.Channel.Title); title != "" {
+		return title
+	}
+	return hostOf(feedURL)
+}
+
 // handlerAddFeed processes the addfeed command, which adds a new feed to the database
-// It expects two arguments: the name of the feed and its URL
-// Usage: gator addfeed <name> <url>
+// It expects the feed URL, optionally preceded by a name that defaults to the channel title
+// The URL may also be a website, whose feed is then discovered from its <link> tags
+// Usage: gator addfeed [name] <url>
 func handlerAddFeed(s *state, cmd command, user database.User) error {
-	// Validate command arguments - exactly two args required (name and url)
-	if len(cmd.Args) != 2 {
-		return fmt.Errorf("usage: %s <name> <url>", cmd.Name)
+	// Validate command arguments - the url, optionally preceded by a name
+	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
+		return fmt.Errorf("usage: %s [name] <url>", cmd.Name)
 	}
 
-	name := cmd.Args[0]
-	url := cmd.Args[1]
+	name := ""
+	rawURL := cmd.Args[len(cmd.Args)-1]
+	if len(cmd.Args) == 2 {
+		name = cmd.Args[0]
+	}
+
+	// Make sure the URL is a feed, discovering it when a website address was given
+	url, result, err := resolveFeed(context.Background(), s, rawURL, os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = feedName(result.Feed, url)
+	}
 
 	// Create a new feed record
 	now := time.Now().UTC()
 	feed, err := s.db.CreateFeed(context.Background(), database.CreateFeedParams{
-		ID:        uuid.New(),
-		CreatedAt: now,
-		UpdatedAt: now,
-		Name:      name,
-		Url:       url,
-		UserID:    user.ID,
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Name:        name,
+		Url:         url,
+		UserID:      user.ID,
+		SiteUrl:     channelSiteURL(result.Feed),
+		Description: channelDescription(result.Feed),
 	})
 
 	if err != nil {
@@ -83,11 +108,46 @@ func handlerAddFeed(s *state, cmd command, user database.User) error {
 		return fmt.Errorf("couldn't follow the feed: %w", err)
 	}
 
+	// Ingest the items that were fetched to validate the feed, so they can be browsed right away
+	saved, err := saveFeedItems(context.Background(), s, feed.ID, result.Feed.Channel.Items)
+	if err != nil {
+		return fmt.Errorf("couldn't save the feed's posts: %w", err)
+	}
+	err = s.db.UpdateFeedCacheHeaders(context.Background(), database.UpdateFeedCacheHeadersParams{
+		ID: feed.ID,
+		Etag: sql.NullString{
+			String: result.ETag,
+			Valid:  result.ETag != "",
+		},
+		LastModified: sql.NullString{
+			String: result.LastModified,
+			Valid:  result.LastModified != "",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't save cache headers: %w", err)
+	}
+	// Later fetches of an unchanged feed are answered with 304, without the channel's polling hints
+	err = storePollingHints(context.Background(), s.db, feed.ID, channelPollingHints(result.Feed))
+	if err != nil {
+		return fmt.Errorf("couldn't save polling hints: %w", err)
+	}
+	if _, err := s.db.MarkFeedFetched(context.Background(), feed.ID); err != nil {
+		return fmt.Errorf("couldn't mark feed fetched: %w", err)
+	}
+
 	// Display the feed information
 	fmt.Println("Feed added successfully:")
 	fmt.Printf("ID: %s\n", feed.ID)
 	fmt.Printf("Name: %s\n", feed.Name)
 	fmt.Printf("URL: %s\n", feed.Url)
+	if feed.SiteUrl.Valid {
+		fmt.Printf("Site: %s\n", feed.SiteUrl.String)
+	}
+	if feed.Description.Valid {
+		fmt.Printf("Description: %s\n", feed.Description.String)
+	}
+	fmt.Printf("Posts: %d\n", saved)
 	fmt.Printf("Created At: %s\n", feed.CreatedAt.Format(time.RFC3339))
 	fmt.Printf("You are now following this feed.\n")
 
@@ -121,13 +181,51 @@ func handlerFeeds(s *state, cmd command, user database.User) error {
 		fmt.Printf("Feed #%d:\n", i+1)
 		fmt.Printf("  Name: %s\n", feed.Name)
 		fmt.Printf("  URL: %s\n", feed.Url)
+		if feed.SiteUrl.Valid {
+			fmt.Printf("  Site: %s\n", feed.SiteUrl.String)
+		}
+		if feed.Description.Valid {
+			fmt.Printf("  Description: %s\n", feed.Description.String)
+		}
 		fmt.Printf("  Created By: %s\n", feed.UserName)
-		fmt.Printf("  Added On: %s\n\n", feed.CreatedAt.Format(time.RFC3339))
+		fmt.Printf("  Added On: %s\n", feed.CreatedAt.Format(time.RFC3339))
+		printFeedFetchStatus(feed)
+		fmt.Println()
 	}
 
 	return nil
 }
 
+// printFeedFetchStatus prints when a feed was last fetched and whether it is currently failing
+func printFeedFetchStatus(feed database.GetAllFeedsWithUsersRow) {
+	if !feed.LastFetchedAt.Valid {
+		fmt.Printf("  Last Fetched: never\n")
+		return
+	}
+	fmt.Printf("  Last Fetched: %s\n", feed.LastFetchedAt.Time.Format(time.RFC3339))
+
+	if feed.DeadAt.Valid {
+		fmt.Printf("  Status: dead, gone since %s and no longer fetched\n", feed.DeadAt.Time.Format(time.RFC3339))
+		return
+	}
+	if feed.NextFetchAt.Valid {
+		fmt.Printf("  Next Fetch: %s\n", feed.NextFetchAt.Time.Format(time.RFC3339))
+	}
+	if feed.ConsecutiveFailures == 0 {
+		fmt.Printf("  Status: OK\n")
+		return
+	}
+	fmt.Printf("  Status: failing (%d consecutive failures)\n", feed.ConsecutiveFailures)
+	if feed.LastError.Valid {
+		fmt.Printf("  Last Error: %s\n", feed.LastError.String)
+	}
+	if feed.LastSuccessAt.Valid {
+		fmt.Printf("  Last Success: %s\n", feed.LastSuccessAt.Time.Format(time.RFC3339))
+	} else {
+		fmt.Printf("  Last Success: never\n")
+	}
+}
+
 // handlerFollowFeed processes the follow command, which allows a user to follow a feed
 // It takes a single URL argument and creates a feed follow record for the current user
 // Usage: gator follow <url>
@@ -176,7 +274,7 @@ func handlerFollowFeed(s *state, cmd command, user database.User) error {
 }
 
 // handlerFollowing processes the following command, which lists all feeds a user is following
-// It takes no arguments and displays all feeds the current user is following
+// It takes no arguments and displays all feeds the current user is following, grouped by folder
 // Usage: gator following
 func handlerFollowing(s *state, cmd command, user database.User) error {
 	// Validate command arguments - no args expected
@@ -196,11 +294,20 @@ func handlerFollowing(s *state, cmd command, user database.User) error {
 		return nil
 	}
 
-	// Display feed follow information
+	// Display feed follow information, the follows are sorted by folder with unfiled feeds last
 	fmt.Printf("User '%s' is following %d feeds:\n\n", user.Name, len(feedFollows))
 	for i, followedFeed := range feedFollows {
-		fmt.Printf("Feed #%d: %s\n", i+1, followedFeed.FeedName)
-		fmt.Printf("  Followed on: %s\n\n", followedFeed.CreatedAt.Format(time.RFC3339))
+		if i == 0 || followedFeed.Folder != feedFollows[i-1].Folder {
+			if followedFeed.Folder.Valid {
+				fmt.Printf("Folder: %s\n\n", followedFeed.Folder.String)
+			} else {
+				fmt.Printf("Not in a folder\n\n")
+			}
+		}
+		fmt.Printf("  Feed #%d: %s\n", i+1, followedFeed.FeedName)
+		fmt.Printf("    URL: %s\n", followedFeed.FeedUrl)
+		fmt.Printf("    Unread posts: %d\n", followedFeed.UnreadCount)
+		fmt.Printf("    Followed on: %s\n\n", followedFeed.CreatedAt.Format(time.RFC3339))
 	}
 
 	return nil
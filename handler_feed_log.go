@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/phihdn/gator/internal/database"
+)
+
+// handlerFeedLog processes the feed-log command, which shows the recent fetch history of a feed
+// It takes the feed URL and an optional number of attempts to show
+// Usage: gator feed-log <url> [limit]
+func handlerFeedLog(s *state, cmd command) error {
+	// Validate command arguments - the url is required, the limit is optional
+	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
+		return fmt.Errorf("usage: %s <url> [limit]", cmd.Name)
+	}
+
+	url := cmd.Args[0]
+
+	// Default limit to 10 if not provided
+	limit := int32(10)
+	if len(cmd.Args) == 2 {
+		parsedLimit, err := strconv.Atoi(cmd.Args[1])
+		if err != nil {
+			return fmt.Errorf("invalid limit: %w", err)
+		}
+		limit = int32(parsedLimit)
+	}
+
+	// Find the feed by URL
+	feed, err := s.db.GetFeedByURL(context.Background(), url)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("no feed found with URL '%s'", url)
+		}
+		return fmt.Errorf("error finding feed: %w", err)
+	}
+
+	// Get the most recent fetch attempts
+	attempts, err := s.db.GetFetchAttemptsForFeed(context.Background(), database.GetFetchAttemptsForFeedParams{
+		FeedID: feed.ID,
+		Limit:  limit,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't get fetch attempts: %w", err)
+	}
+
+	if len(attempts) == 0 {
+		fmt.Printf("Feed '%s' has not been fetched yet\n", feed.Name)
+		return nil
+	}
+
+	// Display the fetch history, newest first
+	fmt.Printf("Last %d fetches of '%s':\n\n", len(attempts), feed.Name)
+	for _, attempt := range attempts {
+		status := "-"
+		if attempt.StatusCode.Valid {
+			status = strconv.Itoa(int(attempt.StatusCode.Int32))
+		}
+
+		fmt.Printf("%s  status %s  %s\n",
+			attempt.StartedAt.Format(time.RFC3339), status, time.Duration(attempt.DurationMs)*time.Millisecond)
+		if attempt.Error.Valid {
+			fmt.Printf("  Error: %s\n", attempt.Error.String)
+			continue
+		}
+		fmt.Printf("  %d posts found, %d saved\n", attempt.PostsFound, attempt.PostsSaved)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/phihdn/gator/internal/database"
+)
+
+// folderSeparator separates the levels of a nested folder path, such as "Tech/Go"
+const folderSeparator = "/"
+
+// handlerFolder processes the folder command, which files a followed feed in one of the
+// user's folders. Folders are per user and can be nested with slashes, as in "Tech/Go".
+// Leaving out the folder takes the feed out of its folder.
+// Usage: gator folder <url> [folder]
+func handlerFolder(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
+		return fmt.Errorf("usage: %s <url> [folder]", cmd.Name)
+	}
+
+	url := cmd.Args[0]
+	folder := ""
+	if len(cmd.Args) == 2 {
+		folder = cleanFolder(cmd.Args[1])
+	}
+
+	feed, err := s.db.GetFeedByURL(context.Background(), url)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("feed with URL '%s' not found", url)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't get feed: %w", err)
+	}
+
+	updated, err := s.db.SetFeedFollowFolder(context.Background(), database.SetFeedFollowFolderParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+		Folder: sql.NullString{
+			String: folder,
+			Valid:  folder != "",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't set folder: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("you are not following the feed '%s'", feed.Name)
+	}
+
+	if folder == "" {
+		fmt.Printf("Removed the feed '%s' from its folder\n", feed.Name)
+		return nil
+	}
+	fmt.Printf("Moved the feed '%s' to folder '%s'\n", feed.Name, folder)
+	return nil
+}
+
+// cleanFolder normalizes a folder path, trimming each level and dropping empty ones
+func cleanFolder(folder string) string {
+	var levels []string
+	for _, level := range strings.Split(folder, folderSeparator) {
+		if level = strings.TrimSpace(level); level != "" {
+			levels = append(levels, level)
+		}
+	}
+	return strings.Join(levels, folderSeparator)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/phihdn/gator/internal/database"
+)
+
+// errAlreadyFollowing is returned by importFeed when the user already follows the feed
+var errAlreadyFollowing = errors.New("already following")
+
+// handlerImport processes the import command, which follows every feed of an OPML file
+// Feeds that aren't known yet are created, and nested outline folders become the follow's folder.
+// The feeds aren't fetched here, the next agg run picks them up.
+// Usage: gator import <file.opml>
+func handlerImport(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %s <file.opml>", cmd.Name)
+	}
+
+	data, err := os.ReadFile(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("couldn't read OPML file: %w", err)
+	}
+	feeds, err := parseOPML(data)
+	if err != nil {
+		return err
+	}
+	if len(feeds) == 0 {
+		fmt.Println("No feeds found in the OPML file")
+		return nil
+	}
+
+	followed, duplicates, failed := 0, 0, 0
+	for _, feed := range feeds {
+		err := importFeed(context.Background(), s, user, feed)
+		switch {
+		case errors.Is(err, errAlreadyFollowing):
+			duplicates++
+			fmt.Printf("Skipped %s: already following\n", feed.URL)
+		case err != nil:
+			failed++
+			fmt.Printf("Failed %s: %v\n", feed.URL, err)
+		default:
+			followed++
+			if feed.Folder != "" {
+				fmt.Printf("Followed %s (%s)\n", feed.URL, feed.Folder)
+			} else {
+				fmt.Printf("Followed %s\n", feed.URL)
+			}
+		}
+	}
+
+	fmt.Printf("\nImported %d feeds: %d followed, %d already followed, %d failed\n",
+		len(feeds), followed, duplicates, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d feeds couldn't be imported", failed)
+	}
+	return nil
+}
+
+// importFeed follows a feed from an OPML file, creating the feed first if nobody has added it yet
+func importFeed(ctx context.Context, s *state, user database.User, feed opmlFeed) error {
+	parsed, err := url.Parse(feed.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid feed URL")
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	now := time.Now().UTC()
+	existing, err := qtx.GetFeedByURL(ctx, feed.URL)
+	if errors.Is(err, sql.ErrNoRows) {
+		name := feed.Title
+		if name == "" {
+			name = hostOf(feed.URL)
+		}
+		existing, err = qtx.CreateFeed(ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			Name:      name,
+			Url:       feed.URL,
+			UserID:    user.ID,
+			SiteUrl: sql.NullString{
+				String: feed.SiteURL,
+				Valid:  feed.SiteURL != "",
+			},
+			Description: sql.NullString{
+				String: feed.Description,
+				Valid:  feed.Description != "",
+			},
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't create feed: %w", err)
+	}
+
+	_, err = qtx.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    user.ID,
+		FeedID:    existing.ID,
+	})
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" && strings.Contains(pgErr.Message, "feed_follows_user_id_feed_id_key") {
+			return errAlreadyFollowing
+		}
+		return fmt.Errorf("couldn't follow feed: %w", err)
+	}
+
+	_, err = qtx.SetFeedFollowFolder(ctx, database.SetFeedFollowFolderParams{
+		UserID: user.ID,
+		FeedID: existing.ID,
+		Folder: sql.NullString{
+			String: feed.Folder,
+			Valid:  feed.Folder != "",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't set folder: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// handlerExport processes the export command, which writes the feeds the user follows as OPML 2.0
+// The document goes to standard output unless a file is given
+// Usage: gator export [file.opml]
+func handlerExport(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) > 1 {
+		return fmt.Errorf("usage: %s [file.opml]", cmd.Name)
+	}
+
+	follows, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("couldn't get feed follows: %w", err)
+	}
+
+	feeds := make([]opmlFeed, 0, len(follows))
+	for _, follow := range follows {
+		feeds = append(feeds, opmlFeed{
+			Title:   follow.FeedName,
+			URL:     follow.FeedUrl,
+			SiteURL: follow.FeedSiteUrl.String,
+			Folder:  follow.Folder.String,
+		})
+	}
+
+	data, err := buildOPML(fmt.Sprintf("%s's feeds in gator", user.Name), feeds, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if len(cmd.Args) == 0 {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(cmd.Args[0], data, 0o644); err != nil {
+		return fmt.Errorf("couldn't write OPML file: %w", err)
+	}
+	fmt.Printf("Exported %d feeds to %s\n", len(feeds), cmd.Args[0])
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phihdn/gator/internal/database"
+)
+
+// handlerMarkRead processes the mark-read command
+// It marks the given posts as read, or every post of a feed and/or published before a date
+// Usage: gator mark-read [--feed url] [--before date] [post_id...]
+func handlerMarkRead(s *state, cmd command, user database.User) error {
+	usage := fmt.Errorf("usage: %v [--feed url] [--before date] [post_id...]", cmd.Name)
+
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	feedURL := flags.String("feed", "", "mark every post of the feed with this URL as read")
+	before := flags.String("before", "", "mark posts published before this date (YYYY-MM-DD or RFC 3339) as read")
+	if err := flags.Parse(cmd.Args); err != nil {
+		return usage
+	}
+
+	// Either specific posts or a selection, but not both
+	filtered := *feedURL != "" || *before != ""
+	if filtered == (flags.NArg() > 0) {
+		return usage
+	}
+
+	if !filtered {
+		return markPostsRead(s, user, flags.Args())
+	}
+
+	params := database.MarkPostsReadParams{UserID: user.ID}
+	if *feedURL != "" {
+		feed, err := s.db.GetFeedByURL(context.Background(), *feedURL)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("feed with URL '%s' not found", *feedURL)
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't get feed: %w", err)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	if *before != "" {
+		date, err := parseDateArg(*before)
+		if err != nil {
+			return err
+		}
+		params.Before = sql.NullTime{Time: date, Valid: true}
+	}
+
+	marked, err := s.db.MarkPostsRead(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("couldn't mark posts as read: %w", err)
+	}
+	fmt.Printf("Marked %d posts as read\n", marked)
+	return nil
+}
+
+// markPostsRead marks the posts with the given IDs as read
+func markPostsRead(s *state, user database.User, ids []string) error {
+	// Check every ID before marking anything
+	postIDs := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		postID, err := uuid.Parse(id)
+		if err != nil {
+			return fmt.Errorf("invalid post ID '%s': %w", id, err)
+		}
+		postIDs = append(postIDs, postID)
+	}
+
+	var marked int64
+	for _, postID := range postIDs {
+		count, err := s.db.MarkPostRead(context.Background(), database.MarkPostReadParams{
+			UserID: user.ID,
+			PostID: postID,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't mark post %s as read: %w", postID, err)
+		}
+		marked += count
+	}
+	fmt.Printf("Marked %d posts as read\n", marked)
+	return nil
+}
+
+// parseDateArg parses a date given on the command line, either a day in local time or an RFC 3339 timestamp
+func parseDateArg(value string) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s', expected YYYY-MM-DD or RFC 3339", value)
+	}
+	return date, nil
+}
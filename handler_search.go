@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/phihdn/gator/internal/database"
+)
+
+// htmlTag matches the markup that search highlights copy from HTML post content
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// handlerSearch processes the search command
+// It runs a full-text search over the posts of the feeds the user follows, best matches first.
+// The query supports web search syntax: "quoted phrases", OR and -excluded words.
+// Usage: gator search [--feed url] [--since date] [--until date] [--limit N] <query>
+func handlerSearch(s *state, cmd command, user database.User) error {
+	usage := fmt.Errorf("usage: %v [--feed url] [--since date] [--until date] [--limit N] <query>", cmd.Name)
+
+	flags := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	feedURL := flags.String("feed", "", "only search the posts of the feed with this URL")
+	since := flags.String("since", "", "only search posts published on or after this date (YYYY-MM-DD or RFC 3339)")
+	until := flags.String("until", "", "only search posts published before this date (YYYY-MM-DD or RFC 3339)")
+	limit := flags.Int("limit", 10, "maximum number of results")
+	if err := flags.Parse(cmd.Args); err != nil {
+		return usage
+	}
+	query := strings.TrimSpace(strings.Join(flags.Args(), " "))
+	if query == "" {
+		return usage
+	}
+	if *limit < 1 {
+		return fmt.Errorf("limit must be at least 1, got %d", *limit)
+	}
+
+	params := database.SearchPostsForUserParams{
+		Query:      query,
+		UserID:     user.ID,
+		MaxResults: int32(*limit),
+	}
+	if *feedURL != "" {
+		feed, err := s.db.GetFeedByURL(context.Background(), *feedURL)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("feed with URL '%s' not found", *feedURL)
+		}
+		if err != nil {
+			return fmt.Errorf("couldn't get feed: %w", err)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+	if *since != "" {
+		date, err := parseDateArg(*since)
+		if err != nil {
+			return err
+		}
+		params.Since = sql.NullTime{Time: date, Valid: true}
+	}
+	if *until != "" {
+		date, err := parseDateArg(*until)
+		if err != nil {
+			return err
+		}
+		params.Until = sql.NullTime{Time: date, Valid: true}
+	}
+
+	results, err := s.db.SearchPostsForUser(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("error searching posts: %w", err)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No posts match '%s'\n", query)
+		return nil
+	}
+
+	fmt.Printf("Found %d posts matching '%s':\n\n", len(results), query)
+	for _, result := range results {
+		fmt.Printf("ID: %s\n", result.ID)
+		fmt.Printf("Feed: %s\n", result.FeedName)
+		fmt.Printf("Title: %s\n", result.Title)
+		if result.PublishedAt.Valid {
+			fmt.Printf("Published: %s\n", result.PublishedAt.Time.Format("Jan 02, 2006"))
+		}
+		fmt.Printf("URL: %s\n", result.Url)
+		// Matching words are wrapped in asterisks
+		fmt.Printf("Match: %s\n", cleanHeadline(result.Headline))
+		fmt.Println("--------------------")
+	}
+
+	return nil
+}
+
+// cleanHeadline turns a search highlight of HTML content into a single line of plain text
+func cleanHeadline(headline string) string {
+	text := html.UnescapeString(htmlTag.ReplaceAllString(headline, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
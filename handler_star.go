@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phihdn/gator/internal/database"
+)
+
+// handlerStar processes the star command, which saves a post for the current user
+// The post is copied into the star, so it stays available even if the post is later
+// deleted or the user stops following its feed
+// Usage: gator star <post_id>
+func handlerStar(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %s <post_id>", cmd.Name)
+	}
+
+	postID, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post ID '%s': %w", cmd.Args[0], err)
+	}
+
+	post, err := s.db.GetPostWithFeed(context.Background(), postID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("post %s not found", postID)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't get post: %w", err)
+	}
+
+	starred, err := s.db.CreatePostStar(context.Background(), database.CreatePostStarParams{
+		ID:          uuid.New(),
+		CreatedAt:   time.Now().UTC(),
+		UserID:      user.ID,
+		PostID:      uuid.NullUUID{UUID: post.ID, Valid: true},
+		Title:       post.Title,
+		Url:         post.Url,
+		Description: post.Description,
+		PublishedAt: post.PublishedAt,
+		FeedName:    post.FeedName,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't star post: %w", err)
+	}
+	if starred == 0 {
+		fmt.Printf("Post '%s' is already starred\n", post.Title)
+		return nil
+	}
+
+	fmt.Printf("Starred post '%s'\n", post.Title)
+	return nil
+}
+
+// handlerUnstar processes the unstar command, which removes a post from the starred list
+// It accepts the ID shown by gator starred, which is the star's own ID once the post is gone
+// Usage: gator unstar <post_id>
+func handlerUnstar(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) != 1 {
+		return fmt.Errorf("usage: %s <post_id>", cmd.Name)
+	}
+
+	id, err := uuid.Parse(cmd.Args[0])
+	if err != nil {
+		return fmt.Errorf("invalid post ID '%s': %w", cmd.Args[0], err)
+	}
+
+	deleted, err := s.db.DeletePostStar(context.Background(), database.DeletePostStarParams{
+		UserID: user.ID,
+		PostID: uuid.NullUUID{UUID: id, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't unstar post: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("post %s is not starred", id)
+	}
+
+	fmt.Printf("Unstarred post %s\n", id)
+	return nil
+}
+
+// handlerStarred processes the starred command, which lists the current user's starred posts
+// Usage: gator starred [limit]
+func handlerStarred(s *state, cmd command, user database.User) error {
+	if len(cmd.Args) > 1 {
+		return fmt.Errorf("usage: %s [limit]", cmd.Name)
+	}
+
+	// Default limit to 10 if not provided
+	limit := int32(10)
+	if len(cmd.Args) == 1 {
+		parsedLimit, err := strconv.Atoi(cmd.Args[0])
+		if err != nil {
+			return fmt.Errorf("invalid limit: %w", err)
+		}
+		limit = int32(parsedLimit)
+	}
+
+	stars, err := s.db.GetStarredPostsForUser(context.Background(), database.GetStarredPostsForUserParams{
+		UserID: user.ID,
+		Limit:  limit,
+	})
+	if err != nil {
+		return fmt.Errorf("error getting starred posts: %w", err)
+	}
+
+	if len(stars) == 0 {
+		fmt.Println("No starred posts. Star one with: gator star <post_id>")
+		return nil
+	}
+
+	fmt.Printf("Found %d starred posts for %s:\n\n", len(stars), user.Name)
+	for _, star := range stars {
+		// Posts that no longer exist are identified by the star itself
+		id := star.ID
+		if star.PostID.Valid {
+			id = star.PostID.UUID
+		}
+
+		fmt.Printf("ID: %s\n", id)
+		fmt.Printf("Feed: %s\n", star.FeedName)
+		fmt.Printf("Title: %s\n", star.Title)
+		if star.PublishedAt.Valid {
+			fmt.Printf("Published: %s\n", star.PublishedAt.Time.Format("Jan 02, 2006"))
+		}
+		fmt.Printf("Starred: %s\n", star.CreatedAt.Format("Jan 02, 2006"))
+		fmt.Printf("URL: %s\n", star.Url)
+		if star.Description.Valid {
+			fmt.Printf("Description: %s\n", star.Description.String)
+		}
+		fmt.Println("--------------------")
+	}
+
+	return nil
+}
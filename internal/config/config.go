@@ -11,9 +11,14 @@ import (
 const configFileName = ".gatorconfig.json"
 
 // Config represents the structure of the JSON configuration file
+// The fetcher settings are optional, zero values mean the aggregator's defaults are used
 type Config struct {
-	DBURL           string `json:"db_url"`
-	CurrentUserName string `json:"current_user_name"`
+	DBURL                       string `json:"db_url"`
+	CurrentUserName             string `json:"current_user_name"`
+	MaxConcurrentPerHost        int    `json:"max_concurrent_per_host,omitempty"`
+	MaxRequestsPerHostPerMinute int    `json:"max_requests_per_host_per_minute,omitempty"`
+	FetchTimeout                string `json:"fetch_timeout,omitempty"`
+	MaxFeedBytes                int64  `json:"max_feed_bytes,omitempty"`
 }
 
 // Read reads the configuration file and returns a Config struct
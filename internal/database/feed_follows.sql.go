@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,7 +20,7 @@ WITH inserted_feed_follow AS (
     VALUES
         ($1, $2, $3, $4, $5)
     RETURNING
-        id, created_at, updated_at, user_id, feed_id
+        id, created_at, updated_at, user_id, feed_id, folder
 )
 SELECT
     inserted_feed_follow.id,
@@ -108,8 +109,28 @@ SELECT
     feed_follows.updated_at,
     feed_follows.user_id,
     feed_follows.feed_id,
+    feed_follows.folder,
     users.name AS user_name,
-    feeds.name AS feed_name
+    feeds.name AS feed_name,
+    feeds.url AS feed_url,
+    feeds.site_url AS feed_site_url,
+    (
+        SELECT
+            COUNT(*)
+        FROM
+            posts
+        WHERE
+            posts.feed_id = feed_follows.feed_id
+            AND NOT EXISTS (
+                SELECT
+                    1
+                FROM
+                    post_reads
+                WHERE
+                    post_reads.post_id = posts.id
+                    AND post_reads.user_id = feed_follows.user_id
+            )
+    ) AS unread_count
 FROM
     feed_follows
     JOIN users ON feed_follows.user_id = users.id
@@ -117,17 +138,22 @@ FROM
 WHERE
     feed_follows.user_id = $1
 ORDER BY
-    feed_follows.created_at DESC
+    feed_follows.folder NULLS LAST,
+    LOWER(feeds.name)
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	FeedID    uuid.UUID
-	UserName  string
-	FeedName  string
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	FeedID      uuid.UUID
+	Folder      sql.NullString
+	UserName    string
+	FeedName    string
+	FeedUrl     string
+	FeedSiteUrl sql.NullString
+	UnreadCount int64
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
@@ -145,8 +171,12 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.Folder,
 			&i.UserName,
 			&i.FeedName,
+			&i.FeedUrl,
+			&i.FeedSiteUrl,
+			&i.UnreadCount,
 		); err != nil {
 			return nil, err
 		}
@@ -160,3 +190,56 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const moveFeedFollows = `-- name: MoveFeedFollows :exec
+UPDATE
+    feed_follows
+SET
+    feed_id = $1,
+    updated_at = NOW()
+WHERE
+    feed_id = $2
+    AND user_id NOT IN (
+        SELECT
+            user_id
+        FROM
+            feed_follows
+        WHERE
+            feed_id = $1
+    )
+`
+
+type MoveFeedFollowsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MoveFeedFollows(ctx context.Context, arg MoveFeedFollowsParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeedFollows, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const setFeedFollowFolder = `-- name: SetFeedFollowFolder :execrows
+UPDATE
+    feed_follows
+SET
+    folder = $3,
+    updated_at = NOW()
+WHERE
+    user_id = $1
+    AND feed_id = $2
+`
+
+type SetFeedFollowFolderParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+	Folder sql.NullString
+}
+
+func (q *Queries) SetFeedFollowFolder(ctx context.Context, arg SetFeedFollowFolderParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setFeedFollowFolder, arg.UserID, arg.FeedID, arg.Folder)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -7,27 +7,40 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO
-    feeds (id, created_at, updated_at, name, url, user_id)
+    feeds (
+        id,
+        created_at,
+        updated_at,
+        name,
+        url,
+        user_id,
+        site_url,
+        description
+    )
 VALUES
-    ($1, $2, $3, $4, $5, $6)
+    ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING
-    id, created_at, updated_at, name, url, user_id, last_fetched_at
+    id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, last_success_at, dead_at, next_fetch_at, site_url, description, ttl_minutes, skip_hours, skip_days
 `
 
 type CreateFeedParams struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Name        string
+	Url         string
+	UserID      uuid.UUID
+	SiteUrl     sql.NullString
+	Description sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -38,6 +51,8 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.SiteUrl,
+		arg.Description,
 	)
 	var i Feed
 	err := row.Scan(
@@ -48,10 +63,36 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.DeadAt,
+		&i.NextFetchAt,
+		&i.SiteUrl,
+		&i.Description,
+		&i.TtlMinutes,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
 	)
 	return i, err
 }
 
+const deleteFeed = `-- name: DeleteFeed :exec
+DELETE FROM
+    feeds
+WHERE
+    id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteFeed, id)
+	return err
+}
+
 const getAllFeedsWithUsers = `-- name: GetAllFeedsWithUsers :many
 SELECT
     f.id,
@@ -60,6 +101,14 @@ SELECT
     f.name,
     f.url,
     f.user_id,
+    f.last_fetched_at,
+    f.consecutive_failures,
+    f.last_error,
+    f.last_success_at,
+    f.dead_at,
+    f.next_fetch_at,
+    f.site_url,
+    f.description,
     u.name AS user_name
 FROM
     feeds f
@@ -69,13 +118,21 @@ ORDER BY
 `
 
 type GetAllFeedsWithUsersRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Url       string
-	UserID    uuid.UUID
-	UserName  string
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Name                string
+	Url                 string
+	UserID              uuid.UUID
+	LastFetchedAt       sql.NullTime
+	ConsecutiveFailures int32
+	LastError           sql.NullString
+	LastSuccessAt       sql.NullTime
+	DeadAt              sql.NullTime
+	NextFetchAt         sql.NullTime
+	SiteUrl             sql.NullString
+	Description         sql.NullString
+	UserName            string
 }
 
 func (q *Queries) GetAllFeedsWithUsers(ctx context.Context) ([]GetAllFeedsWithUsersRow, error) {
@@ -94,6 +151,14 @@ func (q *Queries) GetAllFeedsWithUsers(ctx context.Context) ([]GetAllFeedsWithUs
 			&i.Name,
 			&i.Url,
 			&i.UserID,
+			&i.LastFetchedAt,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.DeadAt,
+			&i.NextFetchAt,
+			&i.SiteUrl,
+			&i.Description,
 			&i.UserName,
 		); err != nil {
 			return nil, err
@@ -111,7 +176,7 @@ func (q *Queries) GetAllFeedsWithUsers(ctx context.Context) ([]GetAllFeedsWithUs
 
 const getFeedByURL = `-- name: GetFeedByURL :one
 SELECT
-    id, created_at, updated_at, name, url, user_id, last_fetched_at
+    id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, last_success_at, dead_at, next_fetch_at, site_url, description, ttl_minutes, skip_hours, skip_days
 FROM
     feeds
 WHERE
@@ -131,13 +196,27 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.DeadAt,
+		&i.NextFetchAt,
+		&i.SiteUrl,
+		&i.Description,
+		&i.TtlMinutes,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
 	)
 	return i, err
 }
 
 const getFeedsByUser = `-- name: GetFeedsByUser :many
 SELECT
-    id, created_at, updated_at, name, url, user_id, last_fetched_at
+    id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, last_success_at, dead_at, next_fetch_at, site_url, description, ttl_minutes, skip_hours, skip_days
 FROM
     feeds
 WHERE
@@ -163,6 +242,20 @@ func (q *Queries) GetFeedsByUser(ctx context.Context, userID uuid.UUID) ([]Feed,
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LeaseOwner,
+			&i.LeaseExpiresAt,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.DeadAt,
+			&i.NextFetchAt,
+			&i.SiteUrl,
+			&i.Description,
+			&i.TtlMinutes,
+			pq.Array(&i.SkipHours),
+			pq.Array(&i.SkipDays),
 		); err != nil {
 			return nil, err
 		}
@@ -177,30 +270,154 @@ func (q *Queries) GetFeedsByUser(ctx context.Context, userID uuid.UUID) ([]Feed,
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT
-    id, created_at, updated_at, name, url, user_id, last_fetched_at
-FROM
+const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
+UPDATE
     feeds
-ORDER BY
-    last_fetched_at ASC NULLS FIRST
-LIMIT
-    1
+SET
+    last_fetched_at = NOW(),
+    updated_at = NOW(),
+    lease_owner = $1::text,
+    lease_expires_at = NOW() + $2::int * INTERVAL '1 second'
+WHERE
+    id IN (
+        SELECT
+            id
+        FROM
+            feeds
+        WHERE
+            dead_at IS NULL
+            AND (
+                lease_expires_at IS NULL
+                OR lease_expires_at < NOW()
+            )
+            AND (
+                last_fetched_at IS NULL
+                OR last_fetched_at < $3::timestamptz
+            )
+            AND (
+                next_fetch_at IS NULL
+                OR next_fetch_at <= NOW()
+            )
+        ORDER BY
+            next_fetch_at ASC NULLS FIRST,
+            last_fetched_at ASC NULLS FIRST
+        LIMIT
+            $4
+        FOR UPDATE
+            SKIP LOCKED
+    )
+RETURNING
+    id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, last_success_at, dead_at, next_fetch_at, site_url, description, ttl_minutes, skip_hours, skip_days
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
-	var i Feed
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Name,
-		&i.Url,
-		&i.UserID,
-		&i.LastFetchedAt,
+type GetNextFeedsToFetchParams struct {
+	LeaseOwner    string
+	LeaseSeconds  int32
+	FetchedBefore time.Time
+	BatchSize     int32
+}
+
+func (q *Queries) GetNextFeedsToFetch(ctx context.Context, arg GetNextFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch,
+		arg.LeaseOwner,
+		arg.LeaseSeconds,
+		arg.FetchedBefore,
+		arg.BatchSize,
 	)
-	return i, err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.LeaseOwner,
+			&i.LeaseExpiresAt,
+			&i.ConsecutiveFailures,
+			&i.LastError,
+			&i.LastSuccessAt,
+			&i.DeadAt,
+			&i.NextFetchAt,
+			&i.SiteUrl,
+			&i.Description,
+			&i.TtlMinutes,
+			pq.Array(&i.SkipHours),
+			pq.Array(&i.SkipDays),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markFeedDead = `-- name: MarkFeedDead :exec
+UPDATE
+    feeds
+SET
+    dead_at = NOW(),
+    updated_at = NOW()
+WHERE
+    id = $1
+`
+
+func (q *Queries) MarkFeedDead(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markFeedDead, id)
+	return err
+}
+
+const markFeedFetchFailed = `-- name: MarkFeedFetchFailed :exec
+UPDATE
+    feeds
+SET
+    consecutive_failures = consecutive_failures + 1,
+    last_error = $1::text,
+    updated_at = NOW()
+WHERE
+    id = $2
+`
+
+type MarkFeedFetchFailedParams struct {
+	LastError string
+	ID        uuid.UUID
+}
+
+func (q *Queries) MarkFeedFetchFailed(ctx context.Context, arg MarkFeedFetchFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetchFailed, arg.LastError, arg.ID)
+	return err
+}
+
+const markFeedFetchSucceeded = `-- name: MarkFeedFetchSucceeded :exec
+UPDATE
+    feeds
+SET
+    consecutive_failures = 0,
+    last_error = NULL,
+    last_success_at = NOW(),
+    updated_at = NOW()
+WHERE
+    id = $1
+`
+
+func (q *Queries) MarkFeedFetchSucceeded(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetchSucceeded, id)
+	return err
 }
 
 const markFeedFetched = `-- name: MarkFeedFetched :one
@@ -212,7 +429,7 @@ SET
 WHERE
     id = $1
 RETURNING
-    id, created_at, updated_at, name, url, user_id, last_fetched_at
+    id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, lease_owner, lease_expires_at, consecutive_failures, last_error, last_success_at, dead_at, next_fetch_at, site_url, description, ttl_minutes, skip_hours, skip_days
 `
 
 func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, error) {
@@ -226,6 +443,156 @@ func (q *Queries) MarkFeedFetched(ctx context.Context, id uuid.UUID) (Feed, erro
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.LeaseOwner,
+		&i.LeaseExpiresAt,
+		&i.ConsecutiveFailures,
+		&i.LastError,
+		&i.LastSuccessAt,
+		&i.DeadAt,
+		&i.NextFetchAt,
+		&i.SiteUrl,
+		&i.Description,
+		&i.TtlMinutes,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
 	)
 	return i, err
 }
+
+const releaseFeedLease = `-- name: ReleaseFeedLease :exec
+UPDATE
+    feeds
+SET
+    lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE
+    id = $1
+    AND lease_owner = $2::text
+`
+
+type ReleaseFeedLeaseParams struct {
+	ID         uuid.UUID
+	LeaseOwner string
+}
+
+func (q *Queries) ReleaseFeedLease(ctx context.Context, arg ReleaseFeedLeaseParams) error {
+	_, err := q.db.ExecContext(ctx, releaseFeedLease, arg.ID, arg.LeaseOwner)
+	return err
+}
+
+const scheduleFeedFetch = `-- name: ScheduleFeedFetch :exec
+UPDATE
+    feeds
+SET
+    next_fetch_at = NOW() + $1::int * INTERVAL '1 second',
+    updated_at = NOW()
+WHERE
+    id = $2
+`
+
+type ScheduleFeedFetchParams struct {
+	DelaySeconds int32
+	ID           uuid.UUID
+}
+
+func (q *Queries) ScheduleFeedFetch(ctx context.Context, arg ScheduleFeedFetchParams) error {
+	_, err := q.db.ExecContext(ctx, scheduleFeedFetch, arg.DelaySeconds, arg.ID)
+	return err
+}
+
+const updateFeedCacheHeaders = `-- name: UpdateFeedCacheHeaders :exec
+UPDATE
+    feeds
+SET
+    etag = $2,
+    last_modified = $3,
+    updated_at = NOW()
+WHERE
+    id = $1
+`
+
+type UpdateFeedCacheHeadersParams struct {
+	ID           uuid.UUID
+	Etag         sql.NullString
+	LastModified sql.NullString
+}
+
+func (q *Queries) UpdateFeedCacheHeaders(ctx context.Context, arg UpdateFeedCacheHeadersParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedCacheHeaders, arg.ID, arg.Etag, arg.LastModified)
+	return err
+}
+
+const updateFeedMetadata = `-- name: UpdateFeedMetadata :exec
+UPDATE
+    feeds
+SET
+    site_url = $2,
+    description = $3,
+    updated_at = NOW()
+WHERE
+    id = $1
+    AND (site_url, description) IS DISTINCT FROM ($2, $3)
+`
+
+type UpdateFeedMetadataParams struct {
+	ID          uuid.UUID
+	SiteUrl     sql.NullString
+	Description sql.NullString
+}
+
+func (q *Queries) UpdateFeedMetadata(ctx context.Context, arg UpdateFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedMetadata, arg.ID, arg.SiteUrl, arg.Description)
+	return err
+}
+
+const updateFeedPollingHints = `-- name: UpdateFeedPollingHints :exec
+UPDATE
+    feeds
+SET
+    ttl_minutes = $2,
+    skip_hours = $3,
+    skip_days = $4,
+    updated_at = NOW()
+WHERE
+    id = $1
+    AND (ttl_minutes, skip_hours, skip_days) IS DISTINCT FROM ($2, $3, $4)
+`
+
+type UpdateFeedPollingHintsParams struct {
+	ID         uuid.UUID
+	TtlMinutes int32
+	SkipHours  []int32
+	SkipDays   []string
+}
+
+func (q *Queries) UpdateFeedPollingHints(ctx context.Context, arg UpdateFeedPollingHintsParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedPollingHints,
+		arg.ID,
+		arg.TtlMinutes,
+		pq.Array(arg.SkipHours),
+		pq.Array(arg.SkipDays),
+	)
+	return err
+}
+
+const updateFeedURL = `-- name: UpdateFeedURL :exec
+UPDATE
+    feeds
+SET
+    url = $2,
+    updated_at = NOW()
+WHERE
+    id = $1
+`
+
+type UpdateFeedURLParams struct {
+	ID  uuid.UUID
+	Url string
+}
+
+func (q *Queries) UpdateFeedURL(ctx context.Context, arg UpdateFeedURLParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedURL, arg.ID, arg.Url)
+	return err
+}
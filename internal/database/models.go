@@ -12,13 +12,27 @@ import (
 )
 
 type Feed struct {
-	ID            uuid.UUID
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Name                string
+	Url                 string
+	UserID              uuid.UUID
+	LastFetchedAt       sql.NullTime
+	Etag                sql.NullString
+	LastModified        sql.NullString
+	LeaseOwner          sql.NullString
+	LeaseExpiresAt      sql.NullTime
+	ConsecutiveFailures int32
+	LastError           sql.NullString
+	LastSuccessAt       sql.NullTime
+	DeadAt              sql.NullTime
+	NextFetchAt         sql.NullTime
+	SiteUrl             sql.NullString
+	Description         sql.NullString
+	TtlMinutes          int32
+	SkipHours           []int32
+	SkipDays            []string
 }
 
 type FeedFollow struct {
@@ -27,6 +41,18 @@ type FeedFollow struct {
 	UpdatedAt time.Time
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Folder    sql.NullString
+}
+
+type FetchAttempt struct {
+	ID         uuid.UUID
+	FeedID     uuid.UUID
+	StartedAt  time.Time
+	DurationMs int32
+	StatusCode sql.NullInt32
+	PostsFound int32
+	PostsSaved int32
+	Error      sql.NullString
 }
 
 type Post struct {
@@ -38,6 +64,51 @@ type Post struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Guid        string
+	Revision    int32
+	Content     sql.NullString
+	Author      sql.NullString
+}
+
+type PostCategory struct {
+	PostID uuid.UUID
+	Name   string
+}
+
+type PostEnclosure struct {
+	PostID uuid.UUID
+	Url    string
+	Type   sql.NullString
+	Length sql.NullInt64
+}
+
+type PostEpisode struct {
+	PostID          uuid.UUID
+	MediaUrl        string
+	MediaType       sql.NullString
+	DurationSeconds sql.NullInt32
+	Episode         sql.NullInt32
+	ImageUrl        sql.NullString
+	TranscriptUrl   sql.NullString
+	TranscriptType  sql.NullString
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostStar struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UserID      uuid.UUID
+	PostID      uuid.NullUUID
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedName    string
 }
 
 type User struct {
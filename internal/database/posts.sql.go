@@ -13,6 +13,36 @@ import (
 	"github.com/google/uuid"
 )
 
+const adoptLegacyPost = `-- name: AdoptLegacyPost :exec
+UPDATE
+    posts
+SET
+    guid = $1
+WHERE
+    id = $2
+    AND guid = url
+    AND guid <> $1
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            posts AS adopted
+        WHERE
+            adopted.feed_id = posts.feed_id
+            AND adopted.guid = $1
+    )
+`
+
+type AdoptLegacyPostParams struct {
+	Guid string
+	ID   uuid.UUID
+}
+
+func (q *Queries) AdoptLegacyPost(ctx context.Context, arg AdoptLegacyPostParams) error {
+	_, err := q.db.ExecContext(ctx, adoptLegacyPost, arg.Guid, arg.ID)
+	return err
+}
+
 const createPost = `-- name: CreatePost :one
 INSERT INTO
     posts (
@@ -23,12 +53,27 @@ INSERT INTO
         url,
         description,
         published_at,
-        feed_id
+        feed_id,
+        guid,
+        content,
+        author
     )
 VALUES
-    ($1, $2, $3, $4, $5, $6, $7, $8)
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET
+    title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    author = EXCLUDED.author,
+    updated_at = EXCLUDED.updated_at,
+    revision = posts.revision + 1
+WHERE
+    (posts.title, posts.description, posts.content, posts.author)
+    IS DISTINCT FROM (EXCLUDED.title, EXCLUDED.description, EXCLUDED.content, EXCLUDED.author)
 RETURNING
-    id, created_at, updated_at, title, url, description, published_at, feed_id
+    id, created_at, updated_at, title, url, description, published_at, feed_id, guid, revision, content, author
 `
 
 type CreatePostParams struct {
@@ -40,6 +85,9 @@ type CreatePostParams struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Guid        string
+	Content     sql.NullString
+	Author      sql.NullString
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -52,6 +100,9 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Guid,
+		arg.Content,
+		arg.Author,
 	)
 	var i Post
 	err := row.Scan(
@@ -63,13 +114,165 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Guid,
+		&i.Revision,
+		&i.Content,
+		&i.Author,
+	)
+	return i, err
+}
+
+const fillPostMetadata = `-- name: FillPostMetadata :exec
+UPDATE
+    posts
+SET
+    content = COALESCE(posts.content, $1),
+    author = COALESCE(posts.author, $2)
+WHERE
+    feed_id = $3
+    AND guid = $4
+    AND (
+        (posts.content IS NULL AND $1::text IS NOT NULL)
+        OR (posts.author IS NULL AND $2::text IS NOT NULL)
+    )
+`
+
+type FillPostMetadataParams struct {
+	Content sql.NullString
+	Author  sql.NullString
+	FeedID  uuid.UUID
+	Guid    string
+}
+
+func (q *Queries) FillPostMetadata(ctx context.Context, arg FillPostMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, fillPostMetadata,
+		arg.Content,
+		arg.Author,
+		arg.FeedID,
+		arg.Guid,
+	)
+	return err
+}
+
+const getFeedPostingInterval = `-- name: GetFeedPostingInterval :one
+SELECT
+    COALESCE(
+        EXTRACT(EPOCH FROM MAX(published_at) - MIN(published_at)) / NULLIF(COUNT(*) - 1, 0),
+        0
+    )::int AS average_seconds
+FROM
+    (
+        SELECT
+            published_at
+        FROM
+            posts
+        WHERE
+            feed_id = $1
+            AND published_at IS NOT NULL
+        ORDER BY
+            published_at DESC
+        LIMIT
+            20
+    ) AS recent_posts
+`
+
+func (q *Queries) GetFeedPostingInterval(ctx context.Context, feedID uuid.UUID) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getFeedPostingInterval, feedID)
+	var average_seconds int32
+	err := row.Scan(&average_seconds)
+	return average_seconds, err
+}
+
+const getLegacyPosts = `-- name: GetLegacyPosts :many
+SELECT
+    id,
+    url
+FROM
+    posts
+WHERE
+    feed_id = $1
+    AND guid = url
+`
+
+type GetLegacyPostsRow struct {
+	ID  uuid.UUID
+	Url string
+}
+
+func (q *Queries) GetLegacyPosts(ctx context.Context, feedID uuid.UUID) ([]GetLegacyPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getLegacyPosts, feedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLegacyPostsRow
+	for rows.Next() {
+		var i GetLegacyPostsRow
+		if err := rows.Scan(&i.ID, &i.Url); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostWithFeed = `-- name: GetPostWithFeed :one
+SELECT
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.guid, posts.revision, posts.content, posts.author,
+    feeds.name AS feed_name
+FROM
+    posts
+    JOIN feeds ON posts.feed_id = feeds.id
+WHERE
+    posts.id = $1
+`
+
+type GetPostWithFeedRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt sql.NullTime
+	FeedID      uuid.UUID
+	Guid        string
+	Revision    int32
+	Content     sql.NullString
+	Author      sql.NullString
+	FeedName    string
+}
+
+func (q *Queries) GetPostWithFeed(ctx context.Context, id uuid.UUID) (GetPostWithFeedRow, error) {
+	row := q.db.QueryRowContext(ctx, getPostWithFeed, id)
+	var i GetPostWithFeedRow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.Guid,
+		&i.Revision,
+		&i.Content,
+		&i.Author,
+		&i.FeedName,
 	)
 	return i, err
 }
 
 const getPostsForUser = `-- name: GetPostsForUser :many
 SELECT
-    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id,
+    posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.guid, posts.revision, posts.content, posts.author,
     feeds.name AS feed_name
 FROM
     posts
@@ -77,15 +280,52 @@ FROM
     JOIN feeds ON posts.feed_id = feeds.id
 WHERE
     feed_follows.user_id = $1
+    AND (
+        $2::text IS NULL
+        OR feed_follows.folder = $2
+        OR STARTS_WITH(feed_follows.folder, $2 || '/')
+    )
+    AND (
+        $3::text IS NULL
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                post_categories
+            WHERE
+                post_categories.post_id = posts.id
+                AND LOWER(post_categories.name) = LOWER($3)
+        )
+    )
+    AND (
+        $4::text IS NULL
+        OR posts.author ILIKE '%' || $4 || '%'
+    )
+    AND (
+        NOT $5::boolean
+        OR NOT EXISTS (
+            SELECT
+                1
+            FROM
+                post_reads
+            WHERE
+                post_reads.post_id = posts.id
+                AND post_reads.user_id = feed_follows.user_id
+        )
+    )
 ORDER BY
     posts.published_at DESC
 LIMIT
-    $2
+    $6
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID     uuid.UUID
+	Folder     sql.NullString
+	Category   sql.NullString
+	Author     sql.NullString
+	UnreadOnly bool
+	MaxPosts   int32
 }
 
 type GetPostsForUserRow struct {
@@ -97,11 +337,22 @@ type GetPostsForUserRow struct {
 	Description sql.NullString
 	PublishedAt sql.NullTime
 	FeedID      uuid.UUID
+	Guid        string
+	Revision    int32
+	Content     sql.NullString
+	Author      sql.NullString
 	FeedName    string
 }
 
 func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.Folder,
+		arg.Category,
+		arg.Author,
+		arg.UnreadOnly,
+		arg.MaxPosts,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +369,142 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Guid,
+			&i.Revision,
+			&i.Content,
+			&i.Author,
+			&i.FeedName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const movePosts = `-- name: MovePosts :exec
+UPDATE
+    posts
+SET
+    feed_id = $1,
+    updated_at = NOW()
+WHERE
+    feed_id = $2
+    AND guid NOT IN (
+        SELECT
+            guid
+        FROM
+            posts
+        WHERE
+            feed_id = $1
+    )
+`
+
+type MovePostsParams struct {
+	ToFeedID   uuid.UUID
+	FromFeedID uuid.UUID
+}
+
+func (q *Queries) MovePosts(ctx context.Context, arg MovePostsParams) error {
+	_, err := q.db.ExecContext(ctx, movePosts, arg.ToFeedID, arg.FromFeedID)
+	return err
+}
+
+const searchPostsForUser = `-- name: SearchPostsForUser :many
+SELECT
+    posts.id,
+    posts.title,
+    posts.url,
+    posts.published_at,
+    feeds.name AS feed_name,
+    ts_headline(
+        'english',
+        COALESCE(posts.content, posts.description, posts.title),
+        websearch_to_tsquery('english', $1),
+        'StartSel=*, StopSel=*, MaxFragments=2, MaxWords=25, MinWords=10'
+    ) AS headline
+FROM
+    posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    JOIN feeds ON posts.feed_id = feeds.id
+WHERE
+    feed_follows.user_id = $2
+    AND (
+        setweight(to_tsvector('english', COALESCE(posts.title, '')), 'A')
+        || setweight(to_tsvector('english', COALESCE(posts.description, '')), 'B')
+        || setweight(to_tsvector('english', COALESCE(posts.content, '')), 'C')
+    ) @@ websearch_to_tsquery('english', $1)
+    AND (
+        $3::uuid IS NULL
+        OR posts.feed_id = $3
+    )
+    AND (
+        $4::timestamptz IS NULL
+        OR COALESCE(posts.published_at, posts.created_at) >= $4
+    )
+    AND (
+        $5::timestamptz IS NULL
+        OR COALESCE(posts.published_at, posts.created_at) < $5
+    )
+ORDER BY
+    ts_rank(
+        setweight(to_tsvector('english', COALESCE(posts.title, '')), 'A')
+        || setweight(to_tsvector('english', COALESCE(posts.description, '')), 'B')
+        || setweight(to_tsvector('english', COALESCE(posts.content, '')), 'C'),
+        websearch_to_tsquery('english', $1)
+    ) DESC,
+    posts.published_at DESC NULLS LAST
+LIMIT
+    $6
+`
+
+type SearchPostsForUserParams struct {
+	Query      string
+	UserID     uuid.UUID
+	FeedID     uuid.NullUUID
+	Since      sql.NullTime
+	Until      sql.NullTime
+	MaxResults int32
+}
+
+type SearchPostsForUserRow struct {
+	ID          uuid.UUID
+	Title       string
+	Url         string
+	PublishedAt sql.NullTime
+	FeedName    string
+	Headline    string
+}
+
+func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUserParams) ([]SearchPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsForUser,
+		arg.Query,
+		arg.UserID,
+		arg.FeedID,
+		arg.Since,
+		arg.Until,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsForUserRow
+	for rows.Next() {
+		var i SearchPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
 			&i.FeedName,
+			&i.Headline,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONFeed represents a parsed JSON Feed 1.1 document
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Description string         `json:"description"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+// JSONFeedItem represents a single item in a JSON Feed
+type JSONFeedItem struct {
+	ID            jsonFeedID           `json:"id"`
+	URL           string               `json:"url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Authors       []JSONFeedAuthor     `json:"authors"`
+	Author        *JSONFeedAuthor      `json:"author"`
+	Tags          []string             `json:"tags"`
+	Attachments   []JSONFeedAttachment `json:"attachments"`
+}
+
+// JSONFeedAuthor represents an item author
+// JSON Feed 1.1 uses an authors list, 1.0 a single author object
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// JSONFeedAttachment represents a file attached to an item, such as a podcast episode
+type JSONFeedAttachment struct {
+	URL               string  `json:"url"`
+	MimeType          string  `json:"mime_type"`
+	SizeInBytes       int64   `json:"size_in_bytes"`
+	DurationInSeconds float64 `json:"duration_in_seconds"`
+}
+
+// jsonFeedID is an item id, which the spec requires to be a string
+// but which some feeds publish as a number
+type jsonFeedID string
+
+func (id *jsonFeedID) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*id = jsonFeedID(text)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("item id must be a string or a number: %w", err)
+	}
+	*id = jsonFeedID(number)
+	return nil
+}
+
+// isJSONFeed reports whether a response looks like a JSON Feed rather than an XML document
+// It trusts a JSON Content-Type and otherwise sniffs the first non-whitespace byte
+func isJSONFeed(body []byte, contentType string) bool {
+	if strings.Contains(strings.ToLower(contentType), "json") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(body), []byte("{"))
+}
+
+// parseJSONFeed parses a JSON Feed document and maps it onto the RSSFeed model
+func parseJSONFeed(body []byte) (*RSSFeed, error) {
+	var jsonFeed JSONFeed
+	if err := json.Unmarshal(body, &jsonFeed); err != nil {
+		return nil, fmt.Errorf("error parsing JSON feed: %w", err)
+	}
+
+	var feed RSSFeed
+	feed.Channel.Title = jsonFeed.Title
+	feed.Channel.Link = jsonFeed.HomePageURL
+	feed.Channel.Description = jsonFeed.Description
+
+	for _, item := range jsonFeed.Items {
+		// Prefer the summary, falling back to the HTML and then the plain text content
+		description := item.Summary
+		if description == "" {
+			description = item.ContentHTML
+		}
+		if description == "" {
+			description = item.ContentText
+		}
+
+		// Prefer the original publication date, falling back to the last modification
+		pubDate := item.DatePublished
+		if pubDate == "" {
+			pubDate = item.DateModified
+		}
+
+		authors := item.Authors
+		if len(authors) == 0 && item.Author != nil {
+			authors = []JSONFeedAuthor{*item.Author}
+		}
+		var names []string
+		for _, author := range authors {
+			if name := strings.TrimSpace(author.Name); name != "" {
+				names = append(names, name)
+			}
+		}
+
+		// Prefer the HTML content, falling back to the plain text
+		content := item.ContentHTML
+		if content == "" {
+			content = item.ContentText
+		}
+
+		var enclosures []RSSEnclosure
+		var duration string
+		for _, attachment := range item.Attachments {
+			if duration == "" && attachment.DurationInSeconds > 0 {
+				duration = strconv.FormatFloat(attachment.DurationInSeconds, 'f', -1, 64)
+			}
+			enclosure := RSSEnclosure{URL: attachment.URL, Type: attachment.MimeType}
+			if attachment.SizeInBytes > 0 {
+				enclosure.Length = strconv.FormatInt(attachment.SizeInBytes, 10)
+			}
+			enclosures = append(enclosures, enclosure)
+		}
+
+		feed.Channel.Items = append(feed.Channel.Items, RSSItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			Description: description,
+			PubDate:     pubDate,
+			GUID:        strings.TrimSpace(string(item.ID)),
+			Content:     content,
+			Author:      strings.Join(names, ", "),
+			Categories:  item.Tags,
+			Enclosures:  enclosures,
+			Duration:    duration,
+		})
+	}
+
+	return &feed, nil
+}
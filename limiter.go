@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMaxConcurrentPerHost is the number of simultaneous requests allowed to one host
+	defaultMaxConcurrentPerHost = 2
+	// defaultMaxRequestsPerHostPerMinute is the number of requests allowed to one host in any minute
+	defaultMaxRequestsPerHostPerMinute = 30
+)
+
+// hostLimiter keeps the fetcher polite by capping the concurrent and per-minute
+// requests made to each host, and by holding off hosts that asked us to back off
+type hostLimiter struct {
+	maxConcurrent int
+	maxPerMinute  int
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// hostState tracks the requests made to a single host
+type hostState struct {
+	active       int
+	recent       []time.Time
+	blockedUntil time.Time
+	// released is closed whenever a request to the host finishes, waking up waiters
+	released chan struct{}
+}
+
+// hostDeferredError is returned by acquire when a host asked us to wait with Retry-After
+type hostDeferredError struct {
+	host  string
+	until time.Time
+}
+
+func (e *hostDeferredError) Error() string {
+	return fmt.Sprintf("host %s asked to retry after %s", e.host, e.until.Format(time.RFC3339))
+}
+
+// newHostLimiter creates a limiter, falling back to the defaults for limits that aren't positive
+func newHostLimiter(maxConcurrent, maxPerMinute int) *hostLimiter {
+	if maxConcurrent <= 0 {
+		maxConcurrent = defaultMaxConcurrentPerHost
+	}
+	if maxPerMinute <= 0 {
+		maxPerMinute = defaultMaxRequestsPerHostPerMinute
+	}
+	return &hostLimiter{
+		maxConcurrent: maxConcurrent,
+		maxPerMinute:  maxPerMinute,
+		hosts:         map[string]*hostState{},
+	}
+}
+
+// hostOf returns the limiter key for a feed URL
+func hostOf(feedURL string) string {
+	parsed, err := url.Parse(feedURL)
+	if err != nil {
+		return feedURL
+	}
+	return strings.ToLower(parsed.Host)
+}
+
+// acquire waits until a request to host is allowed and returns a function that must be
+// called once the request is done. Hosts that are deferred with Retry-After aren't waited
+// for, a *hostDeferredError is returned instead so the caller can reschedule the feed.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	for {
+		l.mu.Lock()
+		state := l.host(host)
+		now := time.Now()
+
+		// Forget requests that are more than a minute old
+		for len(state.recent) > 0 && now.Sub(state.recent[0]) >= time.Minute {
+			state.recent = state.recent[1:]
+		}
+
+		var wait <-chan time.Time
+		switch {
+		case now.Before(state.blockedUntil):
+			until := state.blockedUntil
+			l.mu.Unlock()
+			return nil, &hostDeferredError{host: host, until: until}
+		case len(state.recent) >= l.maxPerMinute:
+			wait = time.After(state.recent[0].Add(time.Minute).Sub(now))
+		case state.active >= l.maxConcurrent:
+			// Wait for one of the active requests to finish
+		default:
+			state.active++
+			state.recent = append(state.recent, now)
+			l.mu.Unlock()
+			return func() { l.release(host) }, nil
+		}
+		released := state.released
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-released:
+		case <-wait:
+		}
+	}
+}
+
+// release marks a request to host as finished and wakes up anyone waiting for it
+func (l *hostLimiter) release(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := l.host(host)
+	state.active--
+	close(state.released)
+	state.released = make(chan struct{})
+}
+
+// deferHost stops requests to host until the given time
+func (l *hostLimiter) deferHost(host string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := l.host(host)
+	if until.After(state.blockedUntil) {
+		state.blockedUntil = until
+	}
+}
+
+// host returns the state of a host, creating it on first use
+// The caller must hold l.mu
+func (l *hostLimiter) host(host string) *hostState {
+	state, ok := l.hosts[host]
+	if !ok {
+		state = &hostState{released: make(chan struct{})}
+		l.hosts[host] = state
+	}
+	return state
+}
@@ -13,9 +13,14 @@ import (
 
 // state represents the application state that is passed to command handlers
 // It contains references to shared resources like configuration and database
+// conn is the underlying connection pool, used to run queries in a transaction
+// fetcher and limiter are shared by every feed fetch made by this process
 type state struct {
-	db  *database.Queries
-	cfg *config.Config
+	db      *database.Queries
+	conn    *sql.DB
+	cfg     *config.Config
+	fetcher *feedFetcher
+	limiter *hostLimiter
 }
 
 func main() {
@@ -41,10 +46,19 @@ func main() {
 	// Initialize database queries
 	dbQueries := database.New(db)
 
+	// Create the HTTP client used to fetch feeds
+	fetcher, err := newFeedFetcher(cfg.FetchTimeout, cfg.MaxFeedBytes)
+	if err != nil {
+		log.Fatalf("error configuring feed fetcher: %v", err)
+	}
+
 	// Initialize application state with loaded configuration and database
 	programState := &state{
-		cfg: &cfg,
-		db:  dbQueries,
+		cfg:     &cfg,
+		db:      dbQueries,
+		conn:    db,
+		fetcher: fetcher,
+		limiter: newHostLimiter(cfg.MaxConcurrentPerHost, cfg.MaxRequestsPerHostPerMinute),
 	}
 
 	// Initialize the commands registry
@@ -60,10 +74,20 @@ func main() {
 	cmds.register("agg", handlerAgg)
 	cmds.register("addfeed", middlewareLoggedIn(handlerAddFeed))
 	cmds.register("feeds", middlewareLoggedIn(handlerFeeds))
+	cmds.register("feed-log", handlerFeedLog)
 	cmds.register("follow", middlewareLoggedIn(handlerFollowFeed))
 	cmds.register("unfollow", middlewareLoggedIn(handlerUnfollowFeed))
 	cmds.register("following", middlewareLoggedIn(handlerFollowing))
+	cmds.register("folder", middlewareLoggedIn(handlerFolder))
+	cmds.register("import", middlewareLoggedIn(handlerImport))
+	cmds.register("export", middlewareLoggedIn(handlerExport))
 	cmds.register("browse", middlewareLoggedIn(handlerBrowse))
+	cmds.register("episodes", middlewareLoggedIn(handlerEpisodes))
+	cmds.register("mark-read", middlewareLoggedIn(handlerMarkRead))
+	cmds.register("star", middlewareLoggedIn(handlerStar))
+	cmds.register("unstar", middlewareLoggedIn(handlerUnstar))
+	cmds.register("starred", middlewareLoggedIn(handlerStarred))
+	cmds.register("search", middlewareLoggedIn(handlerSearch))
 
 	// Ensure at least one command argument is provided
 	if len(os.Args) < 2 {
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OPML represents an OPML 2.0 subscription list
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    OPMLHead `xml:"head"`
+	Body    OPMLBody `xml:"body"`
+}
+
+// OPMLHead holds the document metadata
+type OPMLHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+// OPMLBody holds the top-level outlines
+type OPMLBody struct {
+	Outlines []OPMLOutline `xml:"outline"`
+}
+
+// OPMLOutline is either a feed, when it has an xmlUrl, or a folder of nested outlines
+type OPMLOutline struct {
+	Text        string        `xml:"text,attr"`
+	Title       string        `xml:"title,attr,omitempty"`
+	Type        string        `xml:"type,attr,omitempty"`
+	XMLURL      string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL     string        `xml:"htmlUrl,attr,omitempty"`
+	Description string        `xml:"description,attr,omitempty"`
+	Outlines    []OPMLOutline `xml:"outline"`
+}
+
+// opmlFeed is a feed listed in an OPML document, with the folders it is nested in
+type opmlFeed struct {
+	Title       string
+	URL         string
+	SiteURL     string
+	Description string
+	Folder      string
+}
+
+// parseOPML parses an OPML document and returns its feeds in document order
+func parseOPML(data []byte) ([]opmlFeed, error) {
+	var opml OPML
+	if err := unmarshalXML(data, &opml); err != nil {
+		return nil, fmt.Errorf("error parsing OPML: %w", err)
+	}
+
+	var feeds []opmlFeed
+	var walk func(outlines []OPMLOutline, folders []string)
+	walk = func(outlines []OPMLOutline, folders []string) {
+		for _, outline := range outlines {
+			title := strings.TrimSpace(outline.Title)
+			if title == "" {
+				title = strings.TrimSpace(outline.Text)
+			}
+
+			if feedURL := strings.TrimSpace(outline.XMLURL); feedURL != "" {
+				feeds = append(feeds, opmlFeed{
+					Title:       title,
+					URL:         feedURL,
+					SiteURL:     strings.TrimSpace(outline.HTMLURL),
+					Description: strings.TrimSpace(outline.Description),
+					Folder:      strings.Join(folders, folderSeparator),
+				})
+			}
+
+			// Outlines without a title still group their children, just not under a folder name
+			children := folders
+			if title != "" && outline.XMLURL == "" {
+				children = append(folders[:len(folders):len(folders)], title)
+			}
+			walk(outline.Outlines, children)
+		}
+	}
+	walk(opml.Body.Outlines, nil)
+
+	return feeds, nil
+}
+
+// buildOPML creates an OPML 2.0 document listing the feeds, nesting them in
+// folder outlines according to their folder paths
+func buildOPML(title string, feeds []opmlFeed, now time.Time) ([]byte, error) {
+	// Sort by folder and then by title so that the document is stable between exports
+	sorted := append([]opmlFeed(nil), feeds...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Folder != sorted[j].Folder {
+			return sorted[i].Folder < sorted[j].Folder
+		}
+		return strings.ToLower(sorted[i].Title) < strings.ToLower(sorted[j].Title)
+	})
+
+	var root OPMLOutline
+	for _, feed := range sorted {
+		parent := &root
+		if feed.Folder != "" {
+			for _, folder := range strings.Split(feed.Folder, folderSeparator) {
+				parent = folderOutline(parent, folder)
+			}
+		}
+		parent.Outlines = append(parent.Outlines, OPMLOutline{
+			Text:        feed.Title,
+			Title:       feed.Title,
+			Type:        "rss",
+			XMLURL:      feed.URL,
+			HTMLURL:     feed.SiteURL,
+			Description: feed.Description,
+		})
+	}
+
+	opml := OPML{
+		Version: "2.0",
+		Head: OPMLHead{
+			Title:       title,
+			DateCreated: now.Format(time.RFC1123Z),
+		},
+		Body: OPMLBody{Outlines: root.Outlines},
+	}
+	data, err := xml.MarshalIndent(opml, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding OPML: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// folderOutline returns the child folder of parent with the given name, creating it if needed
+func folderOutline(parent *OPMLOutline, name string) *OPMLOutline {
+	for i := range parent.Outlines {
+		child := &parent.Outlines[i]
+		if child.XMLURL == "" && child.Text == name {
+			return child
+		}
+	}
+	parent.Outlines = append(parent.Outlines, OPMLOutline{Text: name, Title: name})
+	return &parent.Outlines[len(parent.Outlines)-1]
+}
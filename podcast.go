@@ -0,0 +1,109 @@
+package main
+
+import (
+	"database/sql"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/phihdn/gator/internal/database"
+)
+
+// ItunesImage represents an itunes:image element, which carries its URL in the href attribute
+type ItunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+// PodcastTranscript represents a Podcasting 2.0 podcast:transcript element
+type PodcastTranscript struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// episodeParams builds the episode row for a post whose item has a media enclosure
+// It reports false for items that aren't podcast episodes
+func episodeParams(postID uuid.UUID, item RSSItem) (database.CreatePostEpisodeParams, bool) {
+	media, ok := mediaEnclosure(item.Enclosures)
+	if !ok {
+		return database.CreatePostEpisodeParams{}, false
+	}
+
+	params := database.CreatePostEpisodeParams{
+		PostID:   postID,
+		MediaUrl: strings.TrimSpace(media.URL),
+		MediaType: sql.NullString{
+			String: strings.TrimSpace(media.Type),
+			Valid:  strings.TrimSpace(media.Type) != "",
+		},
+		ImageUrl: sql.NullString{
+			String: strings.TrimSpace(item.Image.Href),
+			Valid:  strings.TrimSpace(item.Image.Href) != "",
+		},
+	}
+	if seconds, ok := parseItunesDuration(item.Duration); ok {
+		params.DurationSeconds = sql.NullInt32{Int32: seconds, Valid: true}
+	}
+	if episode, err := strconv.ParseInt(strings.TrimSpace(item.Episode), 10, 32); err == nil && episode > 0 {
+		params.Episode = sql.NullInt32{Int32: int32(episode), Valid: true}
+	}
+	for _, transcript := range item.Transcripts {
+		if transcriptURL := strings.TrimSpace(transcript.URL); transcriptURL != "" {
+			params.TranscriptUrl = sql.NullString{String: transcriptURL, Valid: true}
+			params.TranscriptType = sql.NullString{
+				String: strings.TrimSpace(transcript.Type),
+				Valid:  strings.TrimSpace(transcript.Type) != "",
+			}
+			break
+		}
+	}
+	return params, true
+}
+
+// mediaEnclosure picks the episode's media file: the first audio or video enclosure,
+// or the first enclosure at all when none declares such a type
+func mediaEnclosure(enclosures []RSSEnclosure) (RSSEnclosure, bool) {
+	var fallback *RSSEnclosure
+	for i, enclosure := range enclosures {
+		if strings.TrimSpace(enclosure.URL) == "" {
+			continue
+		}
+		mediaType := strings.ToLower(strings.TrimSpace(enclosure.Type))
+		if strings.HasPrefix(mediaType, "audio/") || strings.HasPrefix(mediaType, "video/") {
+			return enclosure, true
+		}
+		if fallback == nil {
+			fallback = &enclosures[i]
+		}
+	}
+	if fallback == nil {
+		return RSSEnclosure{}, false
+	}
+	return *fallback, true
+}
+
+// parseItunesDuration converts an itunes:duration value to seconds
+// The value is either a number of seconds or HH:MM:SS / MM:SS, optionally with fractional seconds
+func parseItunesDuration(value string) (int32, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+
+	var seconds float64
+	for _, part := range parts {
+		number, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || number < 0 {
+			return 0, false
+		}
+		seconds = seconds*60 + number
+	}
+	if seconds <= 0 || seconds > math.MaxInt32 {
+		return 0, false
+	}
+	return int32(math.Round(seconds)), true
+}
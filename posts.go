@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phihdn/gator/internal/database"
+)
+
+// saveFeedItems saves every item of a fetched feed and returns how many posts were
+// created or updated. Failures to save a single item are logged and skipped; it only
+// stops early, with the context's error, when ctx is canceled.
+func saveFeedItems(ctx context.Context, s *state, feedID uuid.UUID, items []RSSItem) (int, error) {
+	saved := 0
+	for _, item := range items {
+		// Stop between posts rather than mid-insert when the scrape is aborted
+		if err := ctx.Err(); err != nil {
+			return saved, err
+		}
+
+		post, err := savePost(ctx, s, feedID, item)
+		if err != nil {
+			// No row is returned when the feed already has this post, unchanged
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+
+			// Log other errors
+			log.Printf("Error saving post '%s': %v", item.Title, err)
+			continue
+		}
+
+		saved++
+		if post.Revision > 1 {
+			fmt.Printf("Updated post: %s (revision %d)\n", item.Title, post.Revision)
+		} else {
+			fmt.Printf("Saved post: %s\n", item.Title)
+		}
+	}
+	return saved, nil
+}
+
+// adoptLegacyPost switches a post stored before GUIDs were collected, whose GUID is still
+// its URL, to the item's real identity so that it isn't inserted a second time
+// Links are compared normalized, so rotated tracking parameters don't prevent the match
+func adoptLegacyPost(ctx context.Context, qtx *database.Queries, feedID uuid.UUID, guid, link string) error {
+	if link == "" {
+		return nil
+	}
+	legacyPosts, err := qtx.GetLegacyPosts(ctx, feedID)
+	if err != nil {
+		return err
+	}
+	normalized := normalizeURL(link)
+	for _, legacy := range legacyPosts {
+		if normalizeURL(legacy.Url) == normalized {
+			return qtx.AdoptLegacyPost(ctx, database.AdoptLegacyPostParams{
+				Guid: guid,
+				ID:   legacy.ID,
+			})
+		}
+	}
+	return nil
+}
+
+// savePost stores a feed item as a post of the feed, together with its categories,
+// enclosures and podcast episode metadata
+// A post the feed already has is updated when the item changed, and sql.ErrNoRows is
+// returned when it didn't
+func savePost(ctx context.Context, s *state, feedID uuid.UUID, item RSSItem) (database.Post, error) {
+	// Parse the publication date
+	publishedAt, err := parsePubDate(item.PubDate)
+	if err != nil {
+		log.Printf("Warning: could not parse pubDate for post '%s': %v", item.Title, err)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Post{}, fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	guid := postGUID(item)
+	if err := adoptLegacyPost(ctx, qtx, feedID, guid, item.Link); err != nil {
+		return database.Post{}, fmt.Errorf("couldn't adopt existing post: %w", err)
+	}
+
+	content := sql.NullString{
+		String: item.Content,
+		Valid:  strings.TrimSpace(item.Content) != "",
+	}
+	author := sql.NullString{
+		String: item.Author,
+		Valid:  item.Author != "",
+	}
+
+	// Posts stored before content and authors were collected have neither, fill them in
+	// without counting it as an edit
+	err = qtx.FillPostMetadata(ctx, database.FillPostMetadataParams{
+		Content: content,
+		Author:  author,
+		FeedID:  feedID,
+		Guid:    guid,
+	})
+	if err != nil {
+		return database.Post{}, fmt.Errorf("couldn't fill in post metadata: %w", err)
+	}
+
+	// Create the post, or update it when the publisher has edited it since the last fetch
+	post, err := qtx.CreatePost(ctx, database.CreatePostParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+		Title:     item.Title,
+		Url:       item.Link,
+		Description: sql.NullString{
+			String: item.Description,
+			Valid:  item.Description != "",
+		},
+		PublishedAt: publishedAt,
+		FeedID:      feedID,
+		Guid:        guid,
+		Content:     content,
+		Author:      author,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// The post is unchanged, but an adopted GUID or filled-in metadata must still be kept
+		if err := tx.Commit(); err != nil {
+			return post, fmt.Errorf("couldn't commit unchanged post: %w", err)
+		}
+		return post, sql.ErrNoRows
+	}
+	if err != nil {
+		return post, err
+	}
+
+	// Replace the categories and enclosures of an updated post rather than merging them
+	if err := qtx.DeletePostCategories(ctx, post.ID); err != nil {
+		return post, fmt.Errorf("couldn't clear categories: %w", err)
+	}
+	for _, category := range item.Categories {
+		err := qtx.CreatePostCategory(ctx, database.CreatePostCategoryParams{
+			PostID: post.ID,
+			Name:   category,
+		})
+		if err != nil {
+			return post, fmt.Errorf("couldn't save category %s: %w", category, err)
+		}
+	}
+
+	if err := qtx.DeletePostEnclosures(ctx, post.ID); err != nil {
+		return post, fmt.Errorf("couldn't clear enclosures: %w", err)
+	}
+	for _, enclosure := range item.Enclosures {
+		enclosureURL := strings.TrimSpace(enclosure.URL)
+		if enclosureURL == "" {
+			continue
+		}
+		// The length is often missing, or 0 when the publisher doesn't know it
+		length, parseErr := strconv.ParseInt(strings.TrimSpace(enclosure.Length), 10, 64)
+		err := qtx.CreatePostEnclosure(ctx, database.CreatePostEnclosureParams{
+			PostID: post.ID,
+			Url:    enclosureURL,
+			Type: sql.NullString{
+				String: strings.TrimSpace(enclosure.Type),
+				Valid:  strings.TrimSpace(enclosure.Type) != "",
+			},
+			Length: sql.NullInt64{
+				Int64: length,
+				Valid: parseErr == nil && length > 0,
+			},
+		})
+		if err != nil {
+			return post, fmt.Errorf("couldn't save enclosure %s: %w", enclosureURL, err)
+		}
+	}
+
+	if err := qtx.DeletePostEpisode(ctx, post.ID); err != nil {
+		return post, fmt.Errorf("couldn't clear episode: %w", err)
+	}
+	if episode, ok := episodeParams(post.ID, item); ok {
+		if err := qtx.CreatePostEpisode(ctx, episode); err != nil {
+			return post, fmt.Errorf("couldn't save episode: %w", err)
+		}
+	}
+
+	return post, tx.Commit()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RDFFeed represents a parsed RSS 1.0 (RDF) feed
+// Unlike RSS 2.0, items are siblings of the channel rather than children of it
+type RDFFeed struct {
+	Channel struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+	} `xml:"channel"`
+	Items []RDFItem `xml:"item"`
+}
+
+// RDFItem represents a single item in an RSS 1.0 feed
+// The publication date, author and subjects come from Dublin Core elements and the
+// identifier from the rdf:about attribute
+type RDFItem struct {
+	About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Subject     []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+// parseRDFFeed parses an RSS 1.0 document and maps it onto the RSSFeed model
+func parseRDFFeed(body []byte) (*RSSFeed, error) {
+	var rdf RDFFeed
+	if err := unmarshalXML(body, &rdf); err != nil {
+		return nil, fmt.Errorf("error parsing RDF feed: %w", err)
+	}
+
+	var feed RSSFeed
+	feed.Channel.Title = rdf.Channel.Title
+	feed.Channel.Link = strings.TrimSpace(rdf.Channel.Link)
+	feed.Channel.Description = rdf.Channel.Description
+
+	for _, item := range rdf.Items {
+		feed.Channel.Items = append(feed.Channel.Items, RSSItem{
+			Title:       item.Title,
+			Link:        strings.TrimSpace(item.Link),
+			Description: item.Description,
+			PubDate:     strings.TrimSpace(item.Date),
+			GUID:        strings.TrimSpace(item.About),
+			Content:     item.Content,
+			Author:      item.Creator,
+			Categories:  item.Subject,
+		})
+	}
+
+	return &feed, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/phihdn/gator/internal/database"
+)
+
+// moveFeed points a feed at the URL it has permanently moved to
+// If another feed already uses that URL, the two are merged: follows, posts and the
+// fetch log move to the existing feed and the old one is deleted, along with any posts
+// the existing feed already has
+func moveFeed(ctx context.Context, s *state, feed database.Feed, newURL string) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't start transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	target, err := qtx.GetFeedByURL(ctx, newURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Nobody uses the new URL yet, so the feed can simply move
+		err = qtx.UpdateFeedURL(ctx, database.UpdateFeedURLParams{
+			ID:  feed.ID,
+			Url: newURL,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't update feed URL: %w", err)
+		}
+		log.Printf("Feed %s moved from %s to %s", feed.Name, feed.Url, newURL)
+		return tx.Commit()
+	}
+	if err != nil {
+		return fmt.Errorf("error finding feed: %w", err)
+	}
+	if target.ID == feed.ID {
+		return nil
+	}
+
+	// Users following both feeds keep their existing follow of the target,
+	// their follow of the old feed is removed along with it
+	err = qtx.MoveFeedFollows(ctx, database.MoveFeedFollowsParams{
+		ToFeedID:   target.ID,
+		FromFeedID: feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't move feed follows: %w", err)
+	}
+
+	err = qtx.MovePosts(ctx, database.MovePostsParams{
+		ToFeedID:   target.ID,
+		FromFeedID: feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't move posts: %w", err)
+	}
+
+	err = qtx.MoveFetchAttempts(ctx, database.MoveFetchAttemptsParams{
+		ToFeedID:   target.ID,
+		FromFeedID: feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't move fetch log: %w", err)
+	}
+
+	if err := qtx.DeleteFeed(ctx, feed.ID); err != nil {
+		return fmt.Errorf("couldn't delete old feed: %w", err)
+	}
+
+	log.Printf("Feed %s moved to %s, merged into existing feed %s", feed.Name, newURL, target.Name)
+	return tx.Commit()
+}
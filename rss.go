@@ -6,27 +6,61 @@ import (
 	"encoding/xml"
 	"fmt"
 	"html"
-	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // RSSFeed represents a parsed RSS feed
+// TTL, SkipHours and SkipDays are the channel's hints about how often it should be polled
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Items       []RSSItem `xml:"item"`
+		Title string `xml:"title"`
+		// atom:link elements, usually rel="self", must be matched before Link
+		// or their empty content would replace the channel's own <link>
+		AtomLinks   []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
+		Link        string     `xml:"link"`
+		Description string     `xml:"description"`
+		TTL         string     `xml:"ttl"`
+		SkipHours   []string   `xml:"skipHours>hour"`
+		SkipDays    []string   `xml:"skipDays>day"`
+		Items       []RSSItem  `xml:"item"`
 	} `xml:"channel"`
 }
 
 // RSSItem represents a single item in an RSS feed
+// Content holds the full article from content:encoded, while Description is usually a summary
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	// itunes:title, atom:link and itunes:author must be matched before Title, Link and
+	// Author, or whichever comes last in the item would replace the RSS element
+	ItunesTitle  string     `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+	AtomLinks    []AtomLink `xml:"http://www.w3.org/2005/Atom link"`
+	ItunesAuthor string     `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	Description string         `xml:"description"`
+	PubDate     string         `xml:"pubDate"`
+	GUID        string         `xml:"guid"`
+	Content     string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author      string         `xml:"author"`
+	Creator     string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string       `xml:"category"`
+	Enclosures  []RSSEnclosure `xml:"enclosure"`
+
+	// Podcast episode metadata from the iTunes and Podcasting 2.0 namespaces
+	Duration    string              `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	Episode     string              `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd episode"`
+	Image       ItunesImage         `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	Transcripts []PodcastTranscript `xml:"https://podcastindex.org/namespace/1.0 transcript"`
+}
+
+// RSSEnclosure represents a media file attached to an item, such as a podcast episode
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
 }
 
 // parsePubDate attempts to parse the pubDate string from an RSS feed
@@ -42,6 +76,8 @@ func parsePubDate(pubDate string) (sql.NullTime, error) {
 		time.RFC1123,
 		time.RFC822Z,
 		time.RFC822,
+		time.RFC3339,
+		time.RFC3339Nano,
 		"Mon, 02 Jan 2006 15:04:05 -0700",
 		"Mon, 02 Jan 2006 15:04:05 MST",
 		"Mon, 02 Jan 2006 15:04:05 Z",
@@ -51,6 +87,8 @@ func parsePubDate(pubDate string) (sql.NullTime, error) {
 		"2006-01-02 15:04:05",
 		"02 Jan 2006 15:04:05 -0700",
 		"02 Jan 2006 15:04:05 MST",
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02",
 	}
 
 	for _, format := range timeFormats {
@@ -67,8 +105,35 @@ func parsePubDate(pubDate string) (sql.NullTime, error) {
 	return sql.NullTime{Valid: false}, fmt.Errorf("could not parse date: %s", pubDate)
 }
 
-// fetchFeed fetches and parses an RSS feed from the given URL
-func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
+// fetchResult holds the outcome of fetching a feed
+// Feed is nil when the server answered 304 Not Modified
+type fetchResult struct {
+	Feed         *RSSFeed
+	StatusCode   int
+	NotModified  bool
+	ETag         string
+	LastModified string
+	// MovedTo is the final URL when the feed was reached only through permanent redirects
+	MovedTo string
+	// CacheLifetime is how long the server allows the response to be cached
+	CacheLifetime time.Duration
+}
+
+// statusError is returned by fetchFeed when the server answers with an unexpected status code
+// RetryAfter is set when a 429 or 503 response says how long to wait before trying again
+type statusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// fetchFeed fetches and parses an RSS, Atom or JSON feed from the given URL
+// If etag or lastModified are set they are sent as conditional request headers,
+// and a 304 Not Modified response is reported through fetchResult.NotModified
+func (f *feedFetcher) fetchFeed(ctx context.Context, feedURL, etag, lastModified string) (*fetchResult, error) {
 	// Create a new HTTP request with context
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
 	if err != nil {
@@ -77,30 +142,137 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 
 	// Set the User-Agent header to identify our client
 	request.Header.Set("User-Agent", "gator")
+	request.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, */*;q=0.8")
+	request.Header.Set("Accept-Encoding", "gzip, deflate, br")
 
-	// Create an HTTP client and execute the request
-	client := http.Client{}
-	response, err := client.Do(request)
+	// Send the validators from the previous fetch so unchanged feeds aren't downloaded again
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		request.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	// Execute the request with the shared client
+	response, err := f.client.Do(request)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching feed: %w", err)
 	}
 	defer response.Body.Close()
 
+	// Report the new location of a feed that has moved for good
+	movedTo := ""
+	if permanentlyRedirected(response) {
+		movedTo = response.Request.URL.String()
+	}
+
+	// The feed hasn't changed since the last fetch, keep the existing validators
+	if response.StatusCode == http.StatusNotModified {
+		return &fetchResult{
+			StatusCode:    response.StatusCode,
+			NotModified:   true,
+			ETag:          etag,
+			LastModified:  lastModified,
+			MovedTo:       movedTo,
+			CacheLifetime: cacheLifetime(response.Header, time.Now()),
+		}, nil
+	}
+
 	// Check if the response was successful
 	if response.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", response.StatusCode)
+		statusErr := &statusError{StatusCode: response.StatusCode}
+		if response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusServiceUnavailable {
+			statusErr.RetryAfter = parseRetryAfter(response.Header.Get("Retry-After"), time.Now())
+		}
+		return nil, statusErr
 	}
 
-	// Read the response body
-	body, err := io.ReadAll(response.Body)
+	// Read and decompress the response body, within the size limit
+	body, err := f.readBody(response)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
+		return nil, err
 	}
 
-	// Parse the XML content
-	var feed RSSFeed
-	if err := xml.Unmarshal(body, &feed); err != nil {
-		return nil, fmt.Errorf("error parsing feed XML: %w", err)
+	// Web pages are reported with their content so the caller can look for the feeds they link to
+	if isHTMLPage(body, response.Header.Get("Content-Type")) {
+		return nil, &htmlPageError{URL: response.Request.URL.String(), Body: body}
+	}
+
+	// Parse the feed document
+	feed, err := parseFeed(body, response.Header.Get("Content-Type"), response.Request.URL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &fetchResult{
+		Feed:          feed,
+		StatusCode:    response.StatusCode,
+		ETag:          response.Header.Get("ETag"),
+		LastModified:  response.Header.Get("Last-Modified"),
+		MovedTo:       movedTo,
+		CacheLifetime: cacheLifetime(response.Header, time.Now()),
+	}, nil
+}
+
+// permanentlyRedirected reports whether a response was reached through one or more
+// redirects that were all permanent (301 or 308)
+func permanentlyRedirected(response *http.Response) bool {
+	redirected := false
+	for request := response.Request; request.Response != nil; request = request.Response.Request {
+		status := request.Response.StatusCode
+		if status != http.StatusMovedPermanently && status != http.StatusPermanentRedirect {
+			return false
+		}
+		redirected = true
+	}
+	return redirected
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if retryAt, err := http.ParseTime(value); err == nil && retryAt.After(now) {
+		return retryAt.Sub(now)
+	}
+	return 0
+}
+
+// parseFeed detects the format of a feed document and parses it into an RSSFeed
+// RSS 2.0 documents are unmarshalled directly, Atom, RSS 1.0 and JSON Feed documents are mapped onto the same model
+// feedURL is the URL the document was fetched from, which relative Atom links are resolved against
+func parseFeed(body []byte, contentType, feedURL string) (*RSSFeed, error) {
+	// Convert the body to UTF-8 if the server says it uses another character set,
+	// XML documents that only declare their encoding themselves are converted while decoding
+	body, err := transcodeToUTF8(body, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	var feed *RSSFeed
+	if isJSONFeed(body, contentType) {
+		jsonFeed, err := parseJSONFeed(body)
+		if err != nil {
+			return nil, err
+		}
+		feed = jsonFeed
+	} else {
+		xmlFeed, err := parseXMLFeed(body, feedURL)
+		if err != nil {
+			return nil, err
+		}
+		feed = xmlFeed
+	}
+
+	// Fall back to an alternate atom:link for channels without a <link>
+	feed.Channel.Link = strings.TrimSpace(feed.Channel.Link)
+	if feed.Channel.Link == "" {
+		feed.Channel.Link = alternateLink(feed.Channel.AtomLinks)
 	}
 
 	// Unescape HTML entities in the feed title and description
@@ -109,9 +281,95 @@ func fetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
 
 	// Unescape HTML entities in each item's title and description
 	for i := range feed.Channel.Items {
-		feed.Channel.Items[i].Title = html.UnescapeString(feed.Channel.Items[i].Title)
-		feed.Channel.Items[i].Description = html.UnescapeString(feed.Channel.Items[i].Description)
+		item := &feed.Channel.Items[i]
+		if strings.TrimSpace(item.Title) == "" {
+			item.Title = item.ItunesTitle
+		}
+		item.Title = html.UnescapeString(item.Title)
+		item.Description = html.UnescapeString(item.Description)
+
+		item.Link = strings.TrimSpace(item.Link)
+		if item.Link == "" {
+			item.Link = alternateLink(item.AtomLinks)
+		}
+
+		// RSS 2.0 only allows an email address in <author>, so many feeds use dc:creator instead
+		if strings.TrimSpace(item.Author) == "" {
+			item.Author = item.Creator
+		}
+		if strings.TrimSpace(item.Author) == "" {
+			item.Author = item.ItunesAuthor
+		}
+		item.Author = html.UnescapeString(strings.TrimSpace(item.Author))
+		item.Categories = cleanCategories(item.Categories)
 	}
 
-	return &feed, nil
+	return feed, nil
+}
+
+// channelSiteURL returns the website a feed belongs to, for storing on the feed
+func channelSiteURL(feed *RSSFeed) sql.NullString {
+	return sql.NullString{
+		String: feed.Channel.Link,
+		Valid:  feed.Channel.Link != "",
+	}
+}
+
+// channelDescription returns the description of a feed, for storing on the feed
+func channelDescription(feed *RSSFeed) sql.NullString {
+	description := strings.TrimSpace(feed.Channel.Description)
+	return sql.NullString{
+		String: description,
+		Valid:  description != "",
+	}
+}
+
+// cleanCategories trims and unescapes category names, dropping empty and repeated ones
+func cleanCategories(categories []string) []string {
+	var cleaned []string
+	seen := map[string]bool{}
+	for _, category := range categories {
+		category = html.UnescapeString(strings.TrimSpace(category))
+		if category == "" || seen[category] {
+			continue
+		}
+		seen[category] = true
+		cleaned = append(cleaned, category)
+	}
+	return cleaned
+}
+
+// parseXMLFeed parses an XML feed document, dispatching on its root element
+func parseXMLFeed(body []byte, feedURL string) (*RSSFeed, error) {
+	root, err := rootElement(body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing feed XML: %w", err)
+	}
+
+	switch root.Local {
+	case "feed":
+		return parseAtomFeed(body, feedURL)
+	case "RDF":
+		return parseRDFFeed(body)
+	default:
+		var feed RSSFeed
+		if err := unmarshalXML(body, &feed); err != nil {
+			return nil, fmt.Errorf("error parsing feed XML: %w", err)
+		}
+		return &feed, nil
+	}
+}
+
+// rootElement returns the name of the first element in an XML document
+func rootElement(body []byte) (xml.Name, error) {
+	decoder := newXMLDecoder(body)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.Name{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
 }
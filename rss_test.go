@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestParseFeedItemElementPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		item       string
+		wantTitle  string
+		wantLink   string
+		wantAuthor string
+	}{
+		{
+			"namespaced elements after the RSS ones",
+			`<title>Post</title><link>https://example.com/post</link><author>jane@example.com (Jane)</author>
+			<itunes:title>Episode</itunes:title><atom:link rel="self" href="https://example.com/self"/><itunes:author>Show</itunes:author>`,
+			"Post", "https://example.com/post", "jane@example.com (Jane)",
+		},
+		{
+			"namespaced elements before the RSS ones",
+			`<itunes:title>Episode</itunes:title><atom:link rel="self" href="https://example.com/self"/><itunes:author>Show</itunes:author>
+			<title>Post</title><link>https://example.com/post</link><author>jane@example.com (Jane)</author>`,
+			"Post", "https://example.com/post", "jane@example.com (Jane)",
+		},
+		{
+			"only namespaced elements",
+			`<itunes:title>Episode</itunes:title><atom:link href="https://example.com/alternate"/><itunes:author>Show</itunes:author>`,
+			"Episode", "https://example.com/alternate", "Show",
+		},
+		{
+			"dc:creator before itunes:author",
+			`<title>Post</title><dc:creator>Jane</dc:creator><itunes:author>Show</itunes:author>`,
+			"Post", "", "Jane",
+		},
+		{
+			"self link only",
+			`<title>Post</title><atom:link rel="self" href="https://example.com/self"/>`,
+			"Post", "", "",
+		},
+	}
+
+	for _, tt := range tests {
+		body := []byte(`<?xml version="1.0"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"
+  xmlns:atom="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<channel><title>Feed</title><item>` + tt.item + `</item></channel>
+</rss>`)
+		feed, err := parseFeed(body, "application/rss+xml", "https://example.com/feed.xml")
+		if err != nil {
+			t.Fatalf("%s: parseFeed: %v", tt.name, err)
+		}
+		if len(feed.Channel.Items) != 1 {
+			t.Fatalf("%s: got %d items, want 1", tt.name, len(feed.Channel.Items))
+		}
+
+		item := feed.Channel.Items[0]
+		if item.Title != tt.wantTitle {
+			t.Errorf("%s: title = %q, want %q", tt.name, item.Title, tt.wantTitle)
+		}
+		if item.Link != tt.wantLink {
+			t.Errorf("%s: link = %q, want %q", tt.name, item.Link, tt.wantLink)
+		}
+		if item.Author != tt.wantAuthor {
+			t.Errorf("%s: author = %q, want %q", tt.name, item.Author, tt.wantAuthor)
+		}
+	}
+}
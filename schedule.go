@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/phihdn/gator/internal/database"
+)
+
+// defaultFetchInterval is used for feeds whose posting frequency isn't known yet
+const defaultFetchInterval = time.Hour
+
+// feedSchedule holds the hints a feed and its server give about how often to poll it
+type feedSchedule struct {
+	ttl           time.Duration
+	skipHours     map[int]bool
+	skipDays      map[time.Weekday]bool
+	cacheLifetime time.Duration
+}
+
+// pollingHints are the hints a channel gives about when to poll it, in the form stored with the feed
+// They are only in a downloaded body, so they are kept for fetches answered with 304 Not Modified
+type pollingHints struct {
+	ttlMinutes int32
+	skipHours  []int32
+	skipDays   []string
+}
+
+// channelPollingHints reads the <ttl>, <skipHours> and <skipDays> of a downloaded feed,
+// dropping values that aren't valid
+func channelPollingHints(feed *RSSFeed) pollingHints {
+	// The columns are NOT NULL, which nil slices would be sent as
+	hints := pollingHints{
+		skipHours: []int32{},
+		skipDays:  []string{},
+	}
+	channel := feed.Channel
+	if minutes, err := strconv.Atoi(strings.TrimSpace(channel.TTL)); err == nil && minutes > 0 {
+		hints.ttlMinutes = int32(minutes)
+	}
+	for _, hour := range channel.SkipHours {
+		if h, err := strconv.Atoi(strings.TrimSpace(hour)); err == nil && h >= 0 && h < 24 {
+			hints.skipHours = append(hints.skipHours, int32(h))
+		}
+	}
+	for _, day := range channel.SkipDays {
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if strings.EqualFold(strings.TrimSpace(day), weekday.String()) {
+				hints.skipDays = append(hints.skipDays, weekday.String())
+			}
+		}
+	}
+	return hints
+}
+
+// storedPollingHints returns the polling hints saved when the feed was last downloaded
+func storedPollingHints(feed database.Feed) pollingHints {
+	return pollingHints{
+		ttlMinutes: feed.TtlMinutes,
+		skipHours:  feed.SkipHours,
+		skipDays:   feed.SkipDays,
+	}
+}
+
+// storePollingHints saves the polling hints of a downloaded feed with it
+func storePollingHints(ctx context.Context, db *database.Queries, feedID uuid.UUID, hints pollingHints) error {
+	return db.UpdateFeedPollingHints(ctx, database.UpdateFeedPollingHintsParams{
+		ID:         feedID,
+		TtlMinutes: hints.ttlMinutes,
+		SkipHours:  hints.skipHours,
+		SkipDays:   hints.skipDays,
+	})
+}
+
+// newFeedSchedule combines a channel's polling hints with the server's cache lifetime
+func newFeedSchedule(hints pollingHints, cacheLifetime time.Duration) feedSchedule {
+	schedule := feedSchedule{
+		ttl:           time.Duration(hints.ttlMinutes) * time.Minute,
+		skipHours:     map[int]bool{},
+		skipDays:      map[time.Weekday]bool{},
+		cacheLifetime: cacheLifetime,
+	}
+	for _, hour := range hints.skipHours {
+		schedule.skipHours[int(hour)] = true
+	}
+	for _, day := range hints.skipDays {
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			if day == weekday.String() {
+				schedule.skipDays[weekday] = true
+			}
+		}
+	}
+	return schedule
+}
+
+// nextFetchDelay decides how long to wait before fetching a feed again
+// Feeds are polled twice per average gap between their posts, but never sooner than
+// the channel's TTL or the server's cache lifetime allow, and always within [minInterval, maxInterval].
+// The resulting time is then pushed past any hours and days the channel asks to be skipped.
+func nextFetchDelay(now time.Time, postingInterval time.Duration, schedule feedSchedule, minInterval, maxInterval time.Duration) time.Duration {
+	interval := defaultFetchInterval
+	if postingInterval > 0 {
+		interval = postingInterval / 2
+	}
+	interval = max(interval, schedule.ttl, schedule.cacheLifetime)
+	interval = min(max(interval, minInterval), maxInterval)
+
+	// skipHours and skipDays are expressed in GMT, look at most a week ahead
+	next := now.Add(interval).UTC()
+	for i := 0; i < 7*24 && (schedule.skipHours[next.Hour()] || schedule.skipDays[next.Weekday()]); i++ {
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+	return next.Sub(now)
+}
+
+// failureBackoff returns how long to wait before retrying a feed that failed consecutiveFailures times in a row
+// The delay doubles with each failure, starting at 2 minutes and capped at maxInterval
+func failureBackoff(consecutiveFailures int32, maxInterval time.Duration) time.Duration {
+	backoff := time.Minute << min(consecutiveFailures, 20)
+	return min(backoff, maxInterval)
+}
+
+// cacheLifetime returns how long a response may be cached according to its
+// Cache-Control max-age directive or, failing that, its Expires header
+func cacheLifetime(header http.Header, now time.Time) time.Duration {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		if directive == "no-cache" || directive == "no-store" {
+			return 0
+		}
+		if value, ok := strings.CutPrefix(directive, "max-age="); ok {
+			if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil {
+		// Measure against the server's clock when it sent one
+		if date, err := http.ParseTime(header.Get("Date")); err == nil {
+			now = date
+		}
+		if lifetime := expires.Sub(now); lifetime > 0 {
+			return lifetime
+		}
+	}
+	return 0
+}
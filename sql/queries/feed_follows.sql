@@ -27,8 +27,28 @@ SELECT
     feed_follows.updated_at,
     feed_follows.user_id,
     feed_follows.feed_id,
+    feed_follows.folder,
     users.name AS user_name,
-    feeds.name AS feed_name
+    feeds.name AS feed_name,
+    feeds.url AS feed_url,
+    feeds.site_url AS feed_site_url,
+    (
+        SELECT
+            COUNT(*)
+        FROM
+            posts
+        WHERE
+            posts.feed_id = feed_follows.feed_id
+            AND NOT EXISTS (
+                SELECT
+                    1
+                FROM
+                    post_reads
+                WHERE
+                    post_reads.post_id = posts.id
+                    AND post_reads.user_id = feed_follows.user_id
+            )
+    ) AS unread_count
 FROM
     feed_follows
     JOIN users ON feed_follows.user_id = users.id
@@ -36,7 +56,8 @@ FROM
 WHERE
     feed_follows.user_id = $1
 ORDER BY
-    feed_follows.created_at DESC;
+    feed_follows.folder NULLS LAST,
+    LOWER(feeds.name);
 
 -- name: DeleteFeedFollowByUserAndFeedURL :exec
 DELETE FROM
@@ -53,3 +74,30 @@ WHERE
         LIMIT
             1
     );
+
+-- name: MoveFeedFollows :exec
+UPDATE
+    feed_follows
+SET
+    feed_id = sqlc.arg(to_feed_id),
+    updated_at = NOW()
+WHERE
+    feed_id = sqlc.arg(from_feed_id)
+    AND user_id NOT IN (
+        SELECT
+            user_id
+        FROM
+            feed_follows
+        WHERE
+            feed_id = sqlc.arg(to_feed_id)
+    );
+
+-- name: SetFeedFollowFolder :execrows
+UPDATE
+    feed_follows
+SET
+    folder = $3,
+    updated_at = NOW()
+WHERE
+    user_id = $1
+    AND feed_id = $2;
@@ -1,8 +1,17 @@
 -- name: CreateFeed :one
 INSERT INTO
-    feeds (id, created_at, updated_at, name, url, user_id)
+    feeds (
+        id,
+        created_at,
+        updated_at,
+        name,
+        url,
+        user_id,
+        site_url,
+        description
+    )
 VALUES
-    ($1, $2, $3, $4, $5, $6)
+    ($1, $2, $3, $4, $5, $6, $7, $8)
 RETURNING
     *;
 
@@ -24,6 +33,14 @@ SELECT
     f.name,
     f.url,
     f.user_id,
+    f.last_fetched_at,
+    f.consecutive_failures,
+    f.last_error,
+    f.last_success_at,
+    f.dead_at,
+    f.next_fetch_at,
+    f.site_url,
+    f.description,
     u.name AS user_name
 FROM
     feeds f
@@ -52,13 +69,138 @@ WHERE
 RETURNING
     *;
 
--- name: GetNextFeedToFetch :one
-SELECT
-    *
-FROM
+-- name: GetNextFeedsToFetch :many
+UPDATE
     feeds
-ORDER BY
-    last_fetched_at ASC NULLS FIRST
-LIMIT
-    1;
+SET
+    last_fetched_at = NOW(),
+    updated_at = NOW(),
+    lease_owner = sqlc.arg(lease_owner)::text,
+    lease_expires_at = NOW() + sqlc.arg(lease_seconds)::int * INTERVAL '1 second'
+WHERE
+    id IN (
+        SELECT
+            id
+        FROM
+            feeds
+        WHERE
+            dead_at IS NULL
+            AND (
+                lease_expires_at IS NULL
+                OR lease_expires_at < NOW()
+            )
+            AND (
+                last_fetched_at IS NULL
+                OR last_fetched_at < sqlc.arg(fetched_before)::timestamptz
+            )
+            AND (
+                next_fetch_at IS NULL
+                OR next_fetch_at <= NOW()
+            )
+        ORDER BY
+            next_fetch_at ASC NULLS FIRST,
+            last_fetched_at ASC NULLS FIRST
+        LIMIT
+            sqlc.arg(batch_size)
+        FOR UPDATE
+            SKIP LOCKED
+    )
+RETURNING
+    *;
+
+-- name: MarkFeedFetchSucceeded :exec
+UPDATE
+    feeds
+SET
+    consecutive_failures = 0,
+    last_error = NULL,
+    last_success_at = NOW(),
+    updated_at = NOW()
+WHERE
+    id = $1;
 
+-- name: MarkFeedFetchFailed :exec
+UPDATE
+    feeds
+SET
+    consecutive_failures = consecutive_failures + 1,
+    last_error = sqlc.arg(last_error)::text,
+    updated_at = NOW()
+WHERE
+    id = sqlc.arg(id);
+
+-- name: MarkFeedDead :exec
+UPDATE
+    feeds
+SET
+    dead_at = NOW(),
+    updated_at = NOW()
+WHERE
+    id = $1;
+
+-- name: UpdateFeedURL :exec
+UPDATE
+    feeds
+SET
+    url = $2,
+    updated_at = NOW()
+WHERE
+    id = $1;
+
+-- name: DeleteFeed :exec
+DELETE FROM
+    feeds
+WHERE
+    id = $1;
+
+-- name: ScheduleFeedFetch :exec
+UPDATE
+    feeds
+SET
+    next_fetch_at = NOW() + sqlc.arg(delay_seconds)::int * INTERVAL '1 second',
+    updated_at = NOW()
+WHERE
+    id = sqlc.arg(id);
+
+-- name: ReleaseFeedLease :exec
+UPDATE
+    feeds
+SET
+    lease_owner = NULL,
+    lease_expires_at = NULL
+WHERE
+    id = sqlc.arg(id)
+    AND lease_owner = sqlc.arg(lease_owner)::text;
+
+-- name: UpdateFeedCacheHeaders :exec
+UPDATE
+    feeds
+SET
+    etag = $2,
+    last_modified = $3,
+    updated_at = NOW()
+WHERE
+    id = $1;
+
+-- name: UpdateFeedMetadata :exec
+UPDATE
+    feeds
+SET
+    site_url = $2,
+    description = $3,
+    updated_at = NOW()
+WHERE
+    id = $1
+    AND (site_url, description) IS DISTINCT FROM ($2, $3);
+
+-- name: UpdateFeedPollingHints :exec
+UPDATE
+    feeds
+SET
+    ttl_minutes = $2,
+    skip_hours = $3,
+    skip_days = $4,
+    updated_at = NOW()
+WHERE
+    id = $1
+    AND (ttl_minutes, skip_hours, skip_days) IS DISTINCT FROM ($2, $3, $4);
@@ -0,0 +1,54 @@
+-- name: CreateFetchAttempt :one
+INSERT INTO
+    fetch_attempts (
+        id,
+        feed_id,
+        started_at,
+        duration_ms,
+        status_code,
+        posts_found,
+        posts_saved,
+        error
+    )
+VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING
+    *;
+
+-- name: GetFetchAttemptsForFeed :many
+SELECT
+    *
+FROM
+    fetch_attempts
+WHERE
+    feed_id = $1
+ORDER BY
+    started_at DESC
+LIMIT
+    $2;
+
+-- name: PruneFetchAttempts :execrows
+DELETE FROM
+    fetch_attempts
+WHERE
+    feed_id = sqlc.arg(feed_id)
+    AND id NOT IN (
+        SELECT
+            id
+        FROM
+            fetch_attempts
+        WHERE
+            feed_id = sqlc.arg(feed_id)
+        ORDER BY
+            started_at DESC
+        LIMIT
+            sqlc.arg(keep)
+    );
+
+-- name: MoveFetchAttempts :exec
+UPDATE
+    fetch_attempts
+SET
+    feed_id = sqlc.arg(to_feed_id)
+WHERE
+    feed_id = sqlc.arg(from_feed_id);
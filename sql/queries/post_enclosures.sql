@@ -0,0 +1,22 @@
+-- name: CreatePostEnclosure :exec
+INSERT INTO
+    post_enclosures (post_id, url, type, length)
+VALUES
+    ($1, $2, $3, $4)
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePostEnclosures :exec
+DELETE FROM
+    post_enclosures
+WHERE
+    post_id = $1;
+
+-- name: GetPostEnclosures :many
+SELECT
+    *
+FROM
+    post_enclosures
+WHERE
+    post_id = $1
+ORDER BY
+    url;
@@ -0,0 +1,43 @@
+-- name: CreatePostEpisode :exec
+INSERT INTO
+    post_episodes (
+        post_id,
+        media_url,
+        media_type,
+        duration_seconds,
+        episode,
+        image_url,
+        transcript_url,
+        transcript_type
+    )
+VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: DeletePostEpisode :exec
+DELETE FROM
+    post_episodes
+WHERE
+    post_id = $1;
+
+-- name: GetEpisodesForUser :many
+SELECT
+    posts.title,
+    posts.published_at,
+    feeds.name AS feed_name,
+    post_episodes.media_url,
+    post_episodes.media_type,
+    post_episodes.duration_seconds,
+    post_episodes.episode,
+    post_episodes.image_url,
+    post_episodes.transcript_url
+FROM
+    post_episodes
+    JOIN posts ON posts.id = post_episodes.post_id
+    JOIN feeds ON feeds.id = posts.feed_id
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE
+    feed_follows.user_id = $1
+ORDER BY
+    posts.published_at DESC NULLS LAST
+LIMIT
+    $2;
@@ -0,0 +1,28 @@
+-- name: MarkPostRead :execrows
+INSERT INTO
+    post_reads (user_id, post_id, read_at)
+VALUES
+    ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: MarkPostsRead :execrows
+INSERT INTO
+    post_reads (user_id, post_id, read_at)
+SELECT
+    feed_follows.user_id,
+    posts.id,
+    NOW()
+FROM
+    posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+WHERE
+    feed_follows.user_id = sqlc.arg(user_id)
+    AND (
+        sqlc.narg(feed_id)::uuid IS NULL
+        OR posts.feed_id = sqlc.narg(feed_id)
+    )
+    AND (
+        sqlc.narg(before)::timestamptz IS NULL
+        OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg(before)
+    )
+ON CONFLICT DO NOTHING;
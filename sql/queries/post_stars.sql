@@ -0,0 +1,38 @@
+-- name: CreatePostStar :execrows
+INSERT INTO
+    post_stars (
+        id,
+        created_at,
+        user_id,
+        post_id,
+        title,
+        url,
+        description,
+        published_at,
+        feed_name
+    )
+VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (user_id, post_id) DO NOTHING;
+
+-- name: DeletePostStar :execrows
+DELETE FROM
+    post_stars
+WHERE
+    user_id = $1
+    AND (
+        post_id = $2
+        OR id = $2
+    );
+
+-- name: GetStarredPostsForUser :many
+SELECT
+    *
+FROM
+    post_stars
+WHERE
+    user_id = $1
+ORDER BY
+    created_at DESC
+LIMIT
+    $2;
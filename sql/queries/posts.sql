@@ -1,3 +1,22 @@
+-- name: AdoptLegacyPost :exec
+UPDATE
+    posts
+SET
+    guid = sqlc.arg(guid)
+WHERE
+    id = sqlc.arg(id)
+    AND guid = url
+    AND guid <> sqlc.arg(guid)
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            posts AS adopted
+        WHERE
+            adopted.feed_id = posts.feed_id
+            AND adopted.guid = sqlc.arg(guid)
+    );
+
 -- name: CreatePost :one
 INSERT INTO
     posts (
@@ -8,13 +27,62 @@ INSERT INTO
         url,
         description,
         published_at,
-        feed_id
+        feed_id,
+        guid,
+        content,
+        author
     )
 VALUES
-    ($1, $2, $3, $4, $5, $6, $7, $8)
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET
+    title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    content = EXCLUDED.content,
+    author = EXCLUDED.author,
+    updated_at = EXCLUDED.updated_at,
+    revision = posts.revision + 1
+WHERE
+    (posts.title, posts.description, posts.content, posts.author)
+    IS DISTINCT FROM (EXCLUDED.title, EXCLUDED.description, EXCLUDED.content, EXCLUDED.author)
 RETURNING
     *;
 
+-- name: FillPostMetadata :exec
+UPDATE
+    posts
+SET
+    content = COALESCE(posts.content, sqlc.narg(content)),
+    author = COALESCE(posts.author, sqlc.narg(author))
+WHERE
+    feed_id = sqlc.arg(feed_id)
+    AND guid = sqlc.arg(guid)
+    AND (
+        (posts.content IS NULL AND sqlc.narg(content)::text IS NOT NULL)
+        OR (posts.author IS NULL AND sqlc.narg(author)::text IS NOT NULL)
+    );
+
+-- name: GetLegacyPosts :many
+SELECT
+    id,
+    url
+FROM
+    posts
+WHERE
+    feed_id = $1
+    AND guid = url;
+
+-- name: GetPostWithFeed :one
+SELECT
+    posts.*,
+    feeds.name AS feed_name
+FROM
+    posts
+    JOIN feeds ON posts.feed_id = feeds.id
+WHERE
+    posts.id = $1;
+
 -- name: GetPostsForUser :many
 SELECT
     posts.*,
@@ -24,8 +92,126 @@ FROM
     JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
     JOIN feeds ON posts.feed_id = feeds.id
 WHERE
-    feed_follows.user_id = $1
+    feed_follows.user_id = sqlc.arg(user_id)
+    AND (
+        sqlc.narg(folder)::text IS NULL
+        OR feed_follows.folder = sqlc.narg(folder)
+        OR STARTS_WITH(feed_follows.folder, sqlc.narg(folder) || '/')
+    )
+    AND (
+        sqlc.narg(category)::text IS NULL
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                post_categories
+            WHERE
+                post_categories.post_id = posts.id
+                AND LOWER(post_categories.name) = LOWER(sqlc.narg(category))
+        )
+    )
+    AND (
+        sqlc.narg(author)::text IS NULL
+        OR posts.author ILIKE '%' || sqlc.narg(author) || '%'
+    )
+    AND (
+        NOT sqlc.arg(unread_only)::boolean
+        OR NOT EXISTS (
+            SELECT
+                1
+            FROM
+                post_reads
+            WHERE
+                post_reads.post_id = posts.id
+                AND post_reads.user_id = feed_follows.user_id
+        )
+    )
 ORDER BY
     posts.published_at DESC
 LIMIT
-    $2;
+    sqlc.arg(max_posts);
+
+-- name: GetFeedPostingInterval :one
+SELECT
+    COALESCE(
+        EXTRACT(EPOCH FROM MAX(published_at) - MIN(published_at)) / NULLIF(COUNT(*) - 1, 0),
+        0
+    )::int AS average_seconds
+FROM
+    (
+        SELECT
+            published_at
+        FROM
+            posts
+        WHERE
+            feed_id = $1
+            AND published_at IS NOT NULL
+        ORDER BY
+            published_at DESC
+        LIMIT
+            20
+    ) AS recent_posts;
+
+-- name: MovePosts :exec
+UPDATE
+    posts
+SET
+    feed_id = sqlc.arg(to_feed_id),
+    updated_at = NOW()
+WHERE
+    feed_id = sqlc.arg(from_feed_id)
+    AND guid NOT IN (
+        SELECT
+            guid
+        FROM
+            posts
+        WHERE
+            feed_id = sqlc.arg(to_feed_id)
+    );
+
+-- name: SearchPostsForUser :many
+SELECT
+    posts.id,
+    posts.title,
+    posts.url,
+    posts.published_at,
+    feeds.name AS feed_name,
+    ts_headline(
+        'english',
+        COALESCE(posts.content, posts.description, posts.title),
+        websearch_to_tsquery('english', sqlc.arg(query)),
+        'StartSel=*, StopSel=*, MaxFragments=2, MaxWords=25, MinWords=10'
+    ) AS headline
+FROM
+    posts
+    JOIN feed_follows ON feed_follows.feed_id = posts.feed_id
+    JOIN feeds ON posts.feed_id = feeds.id
+WHERE
+    feed_follows.user_id = sqlc.arg(user_id)
+    AND (
+        setweight(to_tsvector('english', COALESCE(posts.title, '')), 'A')
+        || setweight(to_tsvector('english', COALESCE(posts.description, '')), 'B')
+        || setweight(to_tsvector('english', COALESCE(posts.content, '')), 'C')
+    ) @@ websearch_to_tsquery('english', sqlc.arg(query))
+    AND (
+        sqlc.narg(feed_id)::uuid IS NULL
+        OR posts.feed_id = sqlc.narg(feed_id)
+    )
+    AND (
+        sqlc.narg(since)::timestamptz IS NULL
+        OR COALESCE(posts.published_at, posts.created_at) >= sqlc.narg(since)
+    )
+    AND (
+        sqlc.narg(until)::timestamptz IS NULL
+        OR COALESCE(posts.published_at, posts.created_at) < sqlc.narg(until)
+    )
+ORDER BY
+    ts_rank(
+        setweight(to_tsvector('english', COALESCE(posts.title, '')), 'A')
+        || setweight(to_tsvector('english', COALESCE(posts.description, '')), 'B')
+        || setweight(to_tsvector('english', COALESCE(posts.content, '')), 'C'),
+        websearch_to_tsquery('english', sqlc.arg(query))
+    ) DESC,
+    posts.published_at DESC NULLS LAST
+LIMIT
+    sqlc.arg(max_results);
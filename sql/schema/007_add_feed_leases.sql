@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN lease_owner TEXT NULL;
+ALTER TABLE feeds ADD COLUMN lease_expires_at TIMESTAMP NULL;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN lease_expires_at;
+ALTER TABLE feeds DROP COLUMN lease_owner;
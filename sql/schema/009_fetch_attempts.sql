@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE fetch_attempts (
+    id UUID PRIMARY KEY,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    started_at TIMESTAMP NOT NULL,
+    duration_ms INTEGER NOT NULL,
+    status_code INTEGER,
+    posts_found INTEGER NOT NULL,
+    posts_saved INTEGER NOT NULL,
+    error TEXT
+);
+
+CREATE INDEX fetch_attempts_feed_id_started_at_idx ON fetch_attempts (feed_id, started_at DESC);
+
+-- +goose Down
+DROP TABLE fetch_attempts;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE posts DROP COLUMN revision;
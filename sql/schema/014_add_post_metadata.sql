@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE posts ADD COLUMN content TEXT;
+ALTER TABLE posts ADD COLUMN author TEXT;
+
+CREATE TABLE post_categories (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    PRIMARY KEY (post_id, name)
+);
+
+CREATE INDEX post_categories_lower_name_idx ON post_categories (LOWER(name));
+
+CREATE TABLE post_enclosures (
+    post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    type TEXT,
+    length BIGINT,
+    PRIMARY KEY (post_id, url)
+);
+
+-- +goose Down
+DROP TABLE post_enclosures;
+DROP TABLE post_categories;
+ALTER TABLE posts DROP COLUMN author;
+ALTER TABLE posts DROP COLUMN content;
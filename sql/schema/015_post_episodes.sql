@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE post_episodes (
+    post_id UUID PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+    media_url TEXT NOT NULL,
+    media_type TEXT,
+    duration_seconds INTEGER,
+    episode INTEGER,
+    image_url TEXT,
+    transcript_url TEXT,
+    transcript_type TEXT
+);
+
+-- +goose Down
+DROP TABLE post_episodes;
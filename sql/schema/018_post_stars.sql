@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE post_stars (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id UUID REFERENCES posts(id) ON DELETE SET NULL,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    description TEXT,
+    published_at TIMESTAMP,
+    feed_name TEXT NOT NULL,
+    UNIQUE (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE post_stars;
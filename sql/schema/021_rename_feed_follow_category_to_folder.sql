@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feed_follows RENAME COLUMN category TO folder;
+
+-- +goose Down
+ALTER TABLE feed_follows RENAME COLUMN folder TO category;
@@ -0,0 +1,21 @@
+-- +goose Up
+DROP INDEX posts_search_vector_idx;
+ALTER TABLE posts DROP COLUMN search_vector;
+
+-- Queries must repeat this expression exactly for the index to be used
+CREATE INDEX posts_search_idx ON posts USING GIN ((
+    setweight(to_tsvector('english', COALESCE(title, '')), 'A')
+    || setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+    || setweight(to_tsvector('english', COALESCE(content, '')), 'C')
+));
+
+-- +goose Down
+DROP INDEX posts_search_idx;
+
+ALTER TABLE posts ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', COALESCE(title, '')), 'A')
+    || setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+    || setweight(to_tsvector('english', COALESCE(content, '')), 'C')
+) STORED;
+
+CREATE INDEX posts_search_vector_idx ON posts USING GIN (search_vector);